@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"gopkg.in/yaml.v3"
+)
+
+// batchEntry is an entry of a batch manifest, listing a Corefile to migrate.
+type batchEntry struct {
+	Path string `yaml:"path"`
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// migrateBatch migrates the Corefiles listed by the batch glob or manifest concurrently, writes the migrated Corefiles
+// and prints a summary of the batch.  Corefiles that cannot be read fail without stopping the others, and partially
+// migrated Corefiles (see --continue-on-error) are written along with their errors.
+func migrateBatch(out io.Writer, batch, from, to string, resolve func(string) (string, error), outputDir string, workers int, opts migration.MigrateOptions) error {
+	jobs, readErrs, err := batchJobsFromPath(batch, from, to, resolve)
+	if err != nil {
+		return fmt.Errorf("error while reading the batch: %v \n", err)
+	}
+	readable := []migration.BatchJob{}
+	for i, job := range jobs {
+		if readErrs[i] == nil {
+			readable = append(readable, job)
+		}
+	}
+	migrated := migration.MigrateBatch(readable, opts, workers)
+	results := make([]migration.BatchResult, len(jobs))
+	for i, job := range jobs {
+		if readErrs[i] != nil {
+			results[i] = migration.BatchResult{Job: job, Err: readErrs[i]}
+			continue
+		}
+		results[i], migrated = migrated[0], migrated[1:]
+	}
+
+	written := map[string]string{} // the Corefiles written, by output path
+	for i, r := range results {
+		var errs migration.MigrationErrors
+		if r.Err != nil && !errors.As(r.Err, &errs) {
+			continue
+		}
+		path := batchOutputPath(r.Job.Name, r.Job.To, outputDir)
+		if other, ok := written[path]; ok {
+			results[i].Err = fmt.Errorf("output path '%v' is already written for '%v'", path, other)
+			continue
+		}
+		if err := writeBatchResult(path, r.Corefile); err != nil {
+			results[i].Err = err
+			continue
+		}
+		written[path] = r.Job.Name
+	}
+	failed := printBatchSummary(out, results)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d Corefiles failed to migrate", failed, len(results))
+	}
+	return nil
+}
+
+// batchJobsFromPath returns the migration jobs of the Corefiles matching the glob, or listed by the manifest if the
+// path is a YAML file, along with the error reading the Corefile of each job, if any.  Versions missing from the
+// manifest entries default to from and to, and versions are resolved by resolve.  Relative paths in a manifest are
+// relative to the manifest.
+func batchJobsFromPath(batch, from, to string, resolve func(string) (string, error)) ([]migration.BatchJob, []error, error) {
+	entries := []batchEntry{}
+	if ext := filepath.Ext(batch); ext == ".yaml" || ext == ".yml" {
+		manifest, err := ioutil.ReadFile(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := yaml.Unmarshal(manifest, &entries); err != nil {
+			return nil, nil, fmt.Errorf("invalid batch manifest: %v", err)
+		}
+		for i := range entries {
+			if entries[i].Path == "" {
+				return nil, nil, fmt.Errorf("invalid batch manifest: entry %d has no path", i)
+			}
+			if !filepath.IsAbs(entries[i].Path) {
+				entries[i].Path = filepath.Join(filepath.Dir(batch), entries[i].Path)
+			}
+		}
+	} else {
+		paths, err := filepath.Glob(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			entries = append(entries, batchEntry{Path: path})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("no Corefile found for '%v'", batch)
+	}
+
+	jobs := []migration.BatchJob{}
+	readErrs := []error{}
+	for _, e := range entries {
+		if e.From == "" {
+			e.From = from
+		}
+		if e.To == "" {
+			e.To = to
+		}
+		if e.From == "" || e.To == "" {
+			return nil, nil, fmt.Errorf("no versions to migrate '%v' from and to, set --from and --to", e.Path)
+		}
+		var err error
+		if e.From, err = resolve(e.From); err != nil {
+			return nil, nil, err
+		}
+		if e.To, err = resolve(e.To); err != nil {
+			return nil, nil, err
+		}
+		corefile, err := getCorefileFromPath(e.Path)
+		jobs = append(jobs, migration.BatchJob{Name: e.Path, From: e.From, To: e.To, Corefile: string(corefile)})
+		readErrs = append(readErrs, err)
+	}
+	return jobs, readErrs, nil
+}
+
+// batchOutputPath returns the path the migrated Corefile is written to: the path of the Corefile in the output
+// directory if set, or beside the Corefile with the version migrated to appended otherwise.
+func batchOutputPath(path, to, outputDir string) string {
+	if outputDir == "" {
+		return path + "." + strings.TrimPrefix(to, "v")
+	}
+	// keep the Corefile inside of the output directory, even if its path is absolute or starts with ".."
+	return filepath.Join(outputDir, filepath.Clean(string(filepath.Separator)+path))
+}
+
+func writeBatchResult(path, corefile string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(corefile), 0644)
+}
+
+// printBatchSummary prints a table of the results, with the number of notices per severity, followed by the errors
+// of the failed and partial migrations, and returns the number of failed and partial migrations.
+func printBatchSummary(out io.Writer, results []migration.BatchResult) int {
+	severities := migration.Severities()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := []string{"COREFILE", "FROM", "TO", "RESULT"}
+	for _, sev := range severities {
+		header = append(header, strings.ToUpper(string(sev)))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	counts := map[string]int{}
+	totals := make([]int, len(severities))
+	for _, r := range results {
+		status := "unchanged"
+		var errs migration.MigrationErrors
+		switch {
+		case errors.As(r.Err, &errs):
+			status = "partial"
+		case r.Err != nil:
+			status = "failed"
+		case r.Changed:
+			status = "migrated"
+		}
+		counts[status]++
+		row := []string{r.Job.Name, r.Job.From, r.Job.To, status}
+		for i, sev := range severities {
+			n := len(migration.NoticesWithSeverity(r.Notices, sev))
+			totals[i] += n
+			row = append(row, fmt.Sprint(n))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	total := []string{"TOTAL", "", "", ""}
+	for _, n := range totals {
+		total = append(total, fmt.Sprint(n))
+	}
+	fmt.Fprintln(w, strings.Join(total, "\t"))
+	w.Flush()
+	fmt.Fprintf(out, "\n%d migrated, %d unchanged, %d partial, %d failed\n", counts["migrated"], counts["unchanged"], counts["partial"], counts["failed"])
+
+	if counts["partial"]+counts["failed"] > 0 {
+		fmt.Fprintln(out, "\nErrors:")
+	}
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		var errs migration.MigrationErrors
+		if errors.As(r.Err, &errs) {
+			for _, e := range errs {
+				fmt.Fprintf(out, "  %v: %v\n", r.Job.Name, e.Error())
+			}
+			continue
+		}
+		fmt.Fprintf(out, "  %v: %v\n", r.Job.Name, r.Err)
+	}
+	return counts["partial"] + counts["failed"]
+}
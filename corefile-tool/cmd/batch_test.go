@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMigrateCmdBatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	corefiles := map[string]string{
+		"a/Corefile": `.:53 {
+    proxy . /etc/resolv.conf
+}
+`,
+		"b/Corefile": `.:53 {
+    forward . /etc/resolv.conf
+}
+`,
+		"c/Corefile": `.:53 {
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+}
+`,
+	}
+	for name, corefile := range corefiles {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(corefile), 0644); err != nil {
+			t.Fatalf("Unable to write test file %q: %v", path, err)
+		}
+	}
+	manifest := filepath.Join(tmpDir, "clusters.yaml")
+	if err := ioutil.WriteFile(manifest, []byte(`- path: a/Corefile
+  from: 1.3.1
+- path: b/Corefile
+  from: 1.5.0
+  to: 1.5.2
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unreadable := filepath.Join(tmpDir, "unreadable.yaml")
+	if err := ioutil.WriteFile(unreadable, []byte(`- path: a/Corefile
+  from: 1.3.1
+- path: missing/Corefile
+  from: 1.3.1
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	collision := filepath.Join(tmpDir, "collision.yaml")
+	if err := ioutil.WriteFile(collision, []byte(`- path: b/Corefile
+  to: 1.5.1
+- path: b/Corefile
+  to: 1.5.2
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedFiles  map[string]string
+		expectedError  bool
+	}{
+		{
+			name: "manifest beside the Corefiles",
+			flags: map[string]string{
+				"to":           "1.5.0",
+				"batch":        manifest,
+				"deprecations": "true",
+			},
+			expectedOutput: `COREFILE            FROM   TO     RESULT     NEWDEFAULT  DEPRECATED  IGNORED  REMOVED  UNSUPPORTED
+{dir}/a/Corefile  1.3.1  1.5.0  migrated   1           1           0        1        0
+{dir}/b/Corefile  1.5.0  1.5.2  unchanged  0           0           0        0        0
+TOTAL                                      1           1           0        1        0
+
+1 migrated, 1 unchanged, 0 partial, 0 failed
+`,
+			expectedFiles: map[string]string{
+				"a/Corefile.1.5.0": ".:53 {\n    forward . /etc/resolv.conf\n}\n",
+				"b/Corefile.1.5.2": corefiles["b/Corefile"],
+			},
+		},
+		{
+			name: "glob into an output directory",
+			flags: map[string]string{
+				"from":            "1.5.0",
+				"to":              "1.5.2",
+				"batch":           filepath.Join(tmpDir, "*", "Corefile"),
+				"output-dir":      filepath.Join(tmpDir, "out"),
+				"unknown-plugins": "error",
+				"workers":         "2",
+			},
+			expectedOutput: `COREFILE            FROM   TO     RESULT     NEWDEFAULT  DEPRECATED  IGNORED  REMOVED  UNSUPPORTED
+{dir}/a/Corefile  1.5.0  1.5.2  failed     0           0           0        0        2
+{dir}/b/Corefile  1.5.0  1.5.2  unchanged  0           0           0        0        0
+{dir}/c/Corefile  1.5.0  1.5.2  failed     0           0           0        0        2
+TOTAL                                      0           0           0        0        4
+
+0 migrated, 1 unchanged, 0 partial, 2 failed
+`,
+			expectedFiles: map[string]string{
+				filepath.Join("out", tmpDir, "b/Corefile"): corefiles["b/Corefile"],
+			},
+			expectedError: true,
+		},
+		{
+			name: "unreadable Corefile",
+			flags: map[string]string{
+				"to":         "1.5.0",
+				"batch":      unreadable,
+				"output-dir": filepath.Join(tmpDir, "out-unreadable"),
+			},
+			expectedOutput: `COREFILE                  FROM   TO     RESULT     NEWDEFAULT  DEPRECATED  IGNORED  REMOVED  UNSUPPORTED
+{dir}/a/Corefile        1.3.1  1.5.0  migrated   1           1           0        1        0
+{dir}/missing/Corefile  1.3.1  1.5.0  failed     0           0           0        0        0
+TOTAL                                            1           1           0        1        0
+
+1 migrated, 0 unchanged, 0 partial, 1 failed
+`,
+			expectedFiles: map[string]string{
+				filepath.Join("out-unreadable", tmpDir, "a/Corefile"): ".:53 {\n    forward . /etc/resolv.conf\n}\n",
+			},
+			expectedError: true,
+		},
+		{
+			name: "partially migrated Corefile",
+			flags: map[string]string{
+				"from":              "1.3.1",
+				"to":                "1.5.0",
+				"batch":             filepath.Join(tmpDir, "c", "Corefile"),
+				"output-dir":        filepath.Join(tmpDir, "out-partial"),
+				"unknown-plugins":   "error",
+				"continue-on-error": "true",
+			},
+			expectedOutput: `COREFILE          FROM   TO     RESULT   NEWDEFAULT  DEPRECATED  IGNORED  REMOVED  UNSUPPORTED
+{dir}/c/Corefile  1.3.1  1.5.0  partial  1           0           0        0        2
+TOTAL                                    1           0           0        0        2
+
+0 migrated, 0 unchanged, 1 partial, 0 failed
+`,
+			expectedFiles: map[string]string{
+				filepath.Join("out-partial", tmpDir, "c/Corefile"): corefiles["c/Corefile"],
+			},
+			expectedError: true,
+		},
+		{
+			name: "same output path",
+			flags: map[string]string{
+				"from":       "1.5.0",
+				"batch":      collision,
+				"output-dir": filepath.Join(tmpDir, "out-collision"),
+			},
+			expectedOutput: `COREFILE          FROM   TO     RESULT     NEWDEFAULT  DEPRECATED  IGNORED  REMOVED  UNSUPPORTED
+{dir}/b/Corefile  1.5.0  1.5.1  unchanged  0           0           0        0        0
+{dir}/b/Corefile  1.5.0  1.5.2  failed     0           0           0        0        0
+TOTAL                                      0           0           0        0        0
+
+0 migrated, 1 unchanged, 0 partial, 1 failed
+`,
+			expectedFiles: map[string]string{
+				filepath.Join("out-collision", tmpDir, "b/Corefile"): corefiles["b/Corefile"],
+			},
+			expectedError: true,
+		},
+		{
+			name: "no Corefile found",
+			flags: map[string]string{
+				"from":  "1.5.0",
+				"to":    "1.5.2",
+				"batch": filepath.Join(tmpDir, "*", "missing"),
+			},
+			expectedError: true,
+		},
+		{
+			name: "batch and corefile",
+			flags: map[string]string{
+				"from":     "1.5.0",
+				"to":       "1.5.2",
+				"batch":    filepath.Join(tmpDir, "*", "Corefile"),
+				"corefile": filepath.Join(tmpDir, "a", "Corefile"),
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewMigrateCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+			if tc.expectedError != (err != nil) {
+				t.Errorf("Expected error to be %v, got: %v", tc.expectedError, err)
+			}
+
+			if tc.expectedOutput != "" {
+				output := buf.String()
+				if i := strings.Index(output, "\nErrors:\n"); i >= 0 {
+					output = output[:i]
+				}
+				// the Corefile column is as wide as the temporary directory, compare the cells only
+				expected := fields(strings.ReplaceAll(tc.expectedOutput, "{dir}", tmpDir))
+				if fields(output) != expected {
+					t.Errorf("Expected output:\n%v\nGot:\n%v", tc.expectedOutput, output)
+				}
+			}
+			for name, content := range tc.expectedFiles {
+				b, err := ioutil.ReadFile(filepath.Join(tmpDir, name))
+				if err != nil {
+					t.Errorf("Expected file %v to be written: %v", name, err)
+					continue
+				}
+				if string(b) != content {
+					t.Errorf("Expected file %v:\n%v\nGot:\n%v", name, content, string(b))
+				}
+			}
+		})
+	}
+}
+
+// fields returns the lines of s with their fields separated by a single space.
+func fields(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// NewChangelogCmd represents the changelog command
+func NewChangelogCmd(out io.Writer) *cobra.Command {
+	changelogCmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Lists the plugins/options added, deprecated, ignored, removed or newly defaulted between two CoreDNS versions",
+		Example: `# List the changes from CoreDNS v1.3.1 to v1.11.1 as markdown.
+corefile-tool changelog --from 1.3.1 --to 1.11.1 --output markdown`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := versionsFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error while listing changes: %v \n", err)
+			}
+			output, _ := cmd.Flags().GetString("output")
+
+			changes, err := migration.Changes(from, to)
+			if err != nil {
+				return fmt.Errorf("error while listing changes: %v \n", err)
+			}
+			switch output {
+			case "text":
+				for _, c := range changes {
+					fmt.Fprintln(out, c.ToString())
+				}
+			case "markdown":
+				printChangesMarkdown(out, changes)
+			case "json":
+				b, err := json.MarshalIndent(changes, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error while listing changes: %v \n", err)
+				}
+				fmt.Fprintln(out, string(b))
+			default:
+				return fmt.Errorf("invalid output format '%v'", output)
+			}
+			return nil
+		},
+	}
+	changelogCmd.Flags().String("from", "", "Required: The version you are migrating from. ")
+	changelogCmd.MarkFlagRequired("from")
+	changelogCmd.Flags().String("to", "", "Required: The version you are migrating to.")
+	changelogCmd.MarkFlagRequired("to")
+	changelogCmd.Flags().String("output", "text", "The output format. [text | markdown | json]")
+	addNearestPatchFlag(changelogCmd)
+
+	return changelogCmd
+}
+
+// printChangesMarkdown writes the changes as a markdown list, with a section per CoreDNS version.
+func printChangesMarkdown(out io.Writer, changes []migration.Change) {
+	version := ""
+	for _, c := range changes {
+		if c.Version != version {
+			if version != "" {
+				fmt.Fprintln(out)
+			}
+			version = c.Version
+			fmt.Fprintf(out, "## CoreDNS %v\n\n", version)
+		}
+		fmt.Fprintf(out, "* %v\n", markdownChange(c))
+	}
+}
+
+// markdownChange returns a change as a markdown list item, without the version, which is in the section heading.
+func markdownChange(c migration.Change) string {
+	s := ""
+	switch {
+	case c.Rule != "":
+		s += fmt.Sprintf("Rule `%v` in plugin `%v` ", c.Rule, c.Plugin)
+	case c.Option != "":
+		s += fmt.Sprintf("Option `%v` in plugin `%v` ", c.Option, c.Plugin)
+	default:
+		s += fmt.Sprintf("Plugin `%v` ", c.Plugin)
+	}
+	switch c.Kind {
+	case migration.ChangeAdded:
+		s += "is added."
+	case string(migration.SevNewDefault):
+		s += "is added as a default."
+	default:
+		s += "is " + c.Kind + "."
+	}
+	if c.ReplacedBy != "" {
+		s += fmt.Sprintf(" It is replaced by `%v`.", c.ReplacedBy)
+	}
+	if c.Additional != "" {
+		s += " " + c.Additional
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration"
+)
+
+func TestNewChangelogCmd(t *testing.T) {
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name:          "fails if no flags set",
+			expectedError: true,
+		},
+		{
+			name: "text",
+			flags: map[string]string{
+				"from": "1.4.0",
+				"to":   "1.5.0",
+			},
+			expectedOutput: `Option "resyncperiod" in plugin "kubernetes" is deprecated in 1.5.0.
+Option "upstream" in plugin "kubernetes" is ignored in 1.5.0.
+Plugin "proxy" is removed in 1.5.0. It is replaced by "forward".
+Plugin "ready" is added as a default in 1.5.0.
+`,
+		},
+		{
+			name: "markdown",
+			flags: map[string]string{
+				"from":   "1.3.1",
+				"to":     "1.5.0",
+				"output": "markdown",
+			},
+			expectedOutput: "## CoreDNS 1.4.0\n\n" +
+				"* Option `endpoint` in plugin `kubernetes` is ignored.\n" +
+				"* Option `upstream` in plugin `kubernetes` is deprecated.\n" +
+				"* Plugin `proxy` is deprecated. It is replaced by `forward`.\n\n" +
+				"## CoreDNS 1.5.0\n\n" +
+				"* Option `resyncperiod` in plugin `kubernetes` is deprecated.\n" +
+				"* Option `upstream` in plugin `kubernetes` is ignored.\n" +
+				"* Plugin `proxy` is removed. It is replaced by `forward`.\n" +
+				"* Plugin `ready` is added as a default.\n",
+		},
+		{
+			name: "json",
+			flags: map[string]string{
+				"from":   "1.6.4",
+				"to":     "1.6.5",
+				"output": "json",
+			},
+			expectedOutput: `[
+  {
+    "version": "1.6.5",
+    "plugin": "health",
+    "option": "lameduck",
+    "kind": "newdefault"
+  }
+]
+`,
+		},
+		{
+			name: "invalid output",
+			flags: map[string]string{
+				"from":   "1.6.4",
+				"to":     "1.6.5",
+				"output": "yaml",
+			},
+			expectedError: true,
+		},
+		{
+			name: "nearest patch release",
+			flags: map[string]string{
+				"from":          "1.4.0",
+				"to":            "1.5.9",
+				"nearest-patch": "true",
+			},
+			expectedOutput: `Option "resyncperiod" in plugin "kubernetes" is deprecated in 1.5.0.
+Option "upstream" in plugin "kubernetes" is ignored in 1.5.0.
+Plugin "proxy" is removed in 1.5.0. It is replaced by "forward".
+Plugin "ready" is added as a default in 1.5.0.
+`,
+		},
+		{
+			name: "unknown patch release",
+			flags: map[string]string{
+				"from": "1.4.0",
+				"to":   "1.5.9",
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid direction",
+			flags: map[string]string{
+				"from": "1.6.5",
+				"to":   "1.6.4",
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewChangelogCmd(&buf)
+
+			// Silence the usage and errors output when testing expected errors.
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+				return
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestMarkdownChange(t *testing.T) {
+	testCases := []struct {
+		change   migration.Change
+		expected string
+	}{
+		{
+			change:   migration.Change{Version: "1.5.0", Plugin: "proxy", Kind: "removed", ReplacedBy: "forward"},
+			expected: "Plugin `proxy` is removed. It is replaced by `forward`.",
+		},
+		{
+			change:   migration.Change{Version: "1.5.0", Plugin: "kubernetes", Option: "upstream", Kind: "ignored"},
+			expected: "Option `upstream` in plugin `kubernetes` is ignored.",
+		},
+		{
+			change:   migration.Change{Version: "1.7.0", Plugin: "rewrite", Rule: "answer name", Kind: migration.ChangeAdded},
+			expected: "Rule `answer name` in plugin `rewrite` is added.",
+		},
+	}
+	for _, tc := range testCases {
+		if got := markdownChange(tc.change); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/coredns/corefile-migration/migration/deployment"
+	"github.com/coredns/corefile-migration/migration/lint"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCheckDeploymentCmd represents the check-deployment command
+func NewCheckDeploymentCmd(out io.Writer) *cobra.Command {
+	checkDeploymentCmd := &cobra.Command{
+		Use:   "check-deployment",
+		Short: "Cross-checks your Corefile against the Deployment/DaemonSet running CoreDNS",
+		Example: `# Check the Corefile against the CoreDNS Deployment.
+corefile-tool check-deployment --corefile /path/to/Corefile --manifest /path/to/coredns.yaml
+
+# Show the manifest changes needed to run the migrated Corefile with CoreDNS v1.11.1.
+corefile-tool check-deployment --corefile /path/to/Corefile --manifest /path/to/coredns.yaml --to 1.11.1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corefile, _ := cmd.Flags().GetString("corefile")
+			manifest, _ := cmd.Flags().GetString("manifest")
+			to, _ := cmd.Flags().GetString("to")
+			failOn, _ := cmd.Flags().GetString("fail-on")
+
+			var threshold lint.Severity
+			if failOn != "" {
+				var err error
+				threshold, err = lint.ParseSeverity(failOn)
+				if err != nil {
+					return err
+				}
+			}
+			findings, err := checkDeploymentFromPath(corefile, manifest, to)
+			if err != nil {
+				return fmt.Errorf("error while checking the deployment: %v \n", err)
+			}
+			failed := false
+			for _, f := range findings {
+				fmt.Fprintln(out, f.ToString())
+				if threshold != "" && f.Severity.AtLeast(threshold) {
+					failed = true
+				}
+			}
+			if failed {
+				// the threshold being met is not a usage error
+				cmd.SilenceUsage = true
+				return &exitError{code: exitCodeThreshold, err: fmt.Errorf("found findings of severity %v or higher", threshold)}
+			}
+			return nil
+		},
+	}
+	checkDeploymentCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
+	checkDeploymentCmd.MarkFlagRequired("corefile")
+	checkDeploymentCmd.Flags().String("manifest", "", "Required: The path of the YAML manifest holding the CoreDNS Deployment or DaemonSet.")
+	checkDeploymentCmd.MarkFlagRequired("manifest")
+	checkDeploymentCmd.Flags().String("to", "", "The CoreDNS version to migrate the Corefile to before checking, from the version of the container image.")
+	checkDeploymentCmd.Flags().String("fail-on", "", fmt.Sprintf("Exit with code %d if any finding of this severity or higher is found. [info | warning | error]", exitCodeThreshold))
+
+	return checkDeploymentCmd
+}
+
+// checkDeploymentFromPath takes the paths where the Corefile and the manifest are located and returns their
+// mismatches.
+func checkDeploymentFromPath(corefilePath, manifestPath, toCoreDNSVersion string) ([]deployment.Finding, error) {
+	fileBytes, err := getCorefileFromPath(corefilePath)
+	if err != nil {
+		return nil, err
+	}
+	manifestBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	return deployment.CheckString(string(fileBytes), manifestBytes, toCoreDNSVersion)
+}
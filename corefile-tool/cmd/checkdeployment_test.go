@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewCheckDeploymentCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+	manifestPath := filepath.Join(tmpDir, "test-manifest")
+
+	corefile := `.:53 {
+    errors
+    health :8081
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+}
+`
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: coredns
+spec:
+  template:
+    spec:
+      containers:
+      - name: coredns
+        image: k8s.gcr.io/coredns:1.3.1
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        - containerPort: 9153
+          name: metrics
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            path: /health
+            port: 8080
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+	if err := ioutil.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", manifestPath, err)
+	}
+
+	testCases := []struct {
+		name             string
+		args             []string
+		expectedOutput   string
+		expectedExitCode int
+	}{
+		{
+			name: "check",
+			args: []string{"--corefile", corefilePath, "--manifest", manifestPath},
+			expectedOutput: `[error] health-probe: server block ".:53", plugin "health": the health plugin listens on port 8081, but the livenessProbe of container "coredns" targets port 8080
+    suggestion: set livenessProbe.httpGet.port of container "coredns" to 8081
+`,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name: "target version and fail on warning",
+			args: []string{"--corefile", corefilePath, "--manifest", manifestPath, "--to", "1.6.7", "--fail-on", "warning"},
+			expectedOutput: `[warning] image: container "coredns" runs CoreDNS 1.3.1, but the Corefile is migrated to 1.6.7
+    suggestion: set the image of container "coredns" to k8s.gcr.io/coredns:1.6.7
+[error] health-probe: server block ".:53", plugin "health": the health plugin listens on port 8081, but the livenessProbe of container "coredns" targets port 8080
+    suggestion: set livenessProbe.httpGet.port of container "coredns" to 8081
+[info] ready-probe: server block ".:53", plugin "ready": no readinessProbe of container "coredns" targets /ready
+    suggestion: add a readinessProbe with httpGet path /ready and port 8181 to container "coredns"
+`,
+			expectedExitCode: exitCodeThreshold,
+		},
+		{
+			name:             "missing manifest",
+			args:             []string{"--corefile", corefilePath, "--manifest", filepath.Join(tmpDir, "no-such-manifest")},
+			expectedExitCode: exitCodeError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewCheckDeploymentCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs(tc.args)
+
+			code := exitCodeOK
+			if err := cmd.Execute(); err != nil {
+				code = exitCodeError
+				if exitErr, ok := err.(*exitError); ok {
+					code = exitErr.code
+				}
+			}
+			if code != tc.expectedExitCode {
+				t.Errorf("Expected exit code %v, got %v", tc.expectedExitCode, code)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", tc.expectedOutput, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats supported by the convert command.
+const (
+	formatCorefile = "corefile"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+)
+
+// NewConvertCmd represents the convert command
+func NewConvertCmd(out io.Writer) *cobra.Command {
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Converts a Corefile to and from its JSON/YAML encoding",
+		Example: `# Convert the Corefile to JSON.
+corefile-tool convert --input /path/to/Corefile --from corefile --to json
+
+# Convert the JSON back to a Corefile.
+corefile-tool convert --input /path/to/Corefile.json --from json --to corefile`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, _ := cmd.Flags().GetString("input")
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+
+			converted, err := convertFromPath(input, from, to)
+			if err != nil {
+				return fmt.Errorf("error while converting the Corefile: %v \n", err)
+			}
+			fmt.Fprint(out, converted)
+			return nil
+		},
+	}
+	convertCmd.Flags().String("input", "", "Required: The path of the Corefile, or of its JSON/YAML encoding.")
+	convertCmd.MarkFlagRequired("input")
+	convertCmd.Flags().String("from", formatCorefile, "The format of the input: corefile, json or yaml.")
+	convertCmd.Flags().String("to", formatJSON, "The format of the output: corefile, json or yaml.")
+
+	return convertCmd
+}
+
+// convertFromPath takes the path of a Corefile in the given format and returns it in the other format.
+func convertFromPath(inputPath, from, to string) (string, error) {
+	fileBytes, err := getCorefileFromPath(inputPath)
+	if err != nil {
+		return "", err
+	}
+	cf, err := decodeCorefile(fileBytes, from)
+	if err != nil {
+		return "", err
+	}
+	return encodeCorefile(cf, to)
+}
+
+func decodeCorefile(data []byte, format string) (*corefile.Corefile, error) {
+	switch format {
+	case formatCorefile:
+		return corefile.New(string(data))
+	case formatJSON:
+		cf := &corefile.Corefile{}
+		if err := json.Unmarshal(data, cf); err != nil {
+			return nil, err
+		}
+		return cf, nil
+	case formatYAML:
+		cf := &corefile.Corefile{}
+		if err := yaml.Unmarshal(data, cf); err != nil {
+			return nil, err
+		}
+		return cf, nil
+	}
+	return nil, fmt.Errorf("unknown format '%v', expected corefile, json or yaml", format)
+}
+
+func encodeCorefile(cf *corefile.Corefile, format string) (string, error) {
+	switch format {
+	case formatCorefile:
+		return cf.ToString(), nil
+	case formatJSON:
+		data, err := json.MarshalIndent(cf, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	case formatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(cf); err != nil {
+			return "", err
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return "", fmt.Errorf("unknown format '%v', expected corefile, json or yaml", format)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConvertCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	corefile := `.:53 { # all zones
+    errors
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+}
+`
+	corefileJSON := `{
+  "schemaVersion": "v1",
+  "servers": [
+    {
+      "domPorts": [
+        ".:53"
+      ],
+      "plugins": [
+        {
+          "name": "errors"
+        },
+        {
+          "name": "forward",
+          "args": [
+            ".",
+            "/etc/resolv.conf"
+          ],
+          "options": [
+            {
+              "name": "max_concurrent",
+              "args": [
+                "1000"
+              ]
+            }
+          ]
+        }
+      ],
+      "comment": "# all zones"
+    }
+  ]
+}
+`
+	corefileYAML := `schemaVersion: v1
+servers:
+  - domPorts:
+      - .:53
+    plugins:
+      - name: errors
+      - name: forward
+        args:
+          - .
+          - /etc/resolv.conf
+        options:
+          - name: max_concurrent
+            args:
+              - "1000"
+    comment: '# all zones'
+`
+	inputs := map[string]string{"corefile": corefile, "json": corefileJSON, "yaml": corefileYAML}
+	paths := map[string]string{}
+	for format, content := range inputs {
+		paths[format] = filepath.Join(tmpDir, "test-corefile."+format)
+		if err := ioutil.WriteFile(paths[format], []byte(content), 0644); err != nil {
+			t.Errorf("Unable to write test file %q: %v", paths[format], err)
+		}
+	}
+
+	formats := []string{"corefile", "json", "yaml"}
+	for _, from := range formats {
+		for _, to := range formats {
+			t.Run(from+" to "+to, func(t *testing.T) {
+				var buf bytes.Buffer
+				cmd := NewConvertCmd(&buf)
+				cmd.SilenceUsage = true
+				cmd.SilenceErrors = true
+				cmd.SetArgs([]string{"--input", paths[from], "--from", from, "--to", to})
+				if err := cmd.Execute(); err != nil {
+					t.Fatal(err)
+				}
+				if buf.String() != inputs[to] {
+					t.Errorf("Expected output %v did not match %v", buf.String(), inputs[to])
+				}
+			})
+		}
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		var buf bytes.Buffer
+		cmd := NewConvertCmd(&buf)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		cmd.SetArgs([]string{"--input", paths["corefile"], "--to", "toml"})
+		if err := cmd.Execute(); err == nil {
+			t.Error("Expected an error, got none")
+		}
+	})
+}
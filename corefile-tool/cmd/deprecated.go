@@ -13,21 +13,24 @@ import (
 func NewDeprecatedCmd(out io.Writer) *cobra.Command {
 	deprecatedCmd := &cobra.Command{
 		Use:   "deprecated",
-		Short: "Deprecated returns a list of deprecated, removed, ignored and new default plugins or directives present in the Corefile.",
+		Short: "Deprecated returns a list of deprecated, removed, ignored and new default plugins or directives present in the Corefile, with one entry per plugin/option and server block.",
 		Example: `# See deprecated, removed, ignored and new default plugins CoreDNS from v1.4.0 to v1.5.0. 
 corefile-tool deprecated --from 1.4.0 --to 1.5.0 --corefile /path/to/Corefile`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			from, _ := cmd.Flags().GetString("from")
-			to, _ := cmd.Flags().GetString("to")
+			from, to, err := versionsFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error while listing deprecated plugins: %v \n", err)
+			}
 			corefile, _ := cmd.Flags().GetString("corefile")
 			deprecated, err := deprecatedCorefileFromPath(from, to, corefile)
 			if err != nil {
 				return fmt.Errorf("error while listing deprecated plugins: %v \n", err)
 			}
-			for _, dep := range deprecated {
-				fmt.Fprintln(out, dep.ToString())
+			deprecated, thresholdErr := noticesFromFlags(cmd, deprecated)
+			for _, tl := range migration.GroupNotices(deprecated) {
+				fmt.Fprintln(out, tl.ToString())
 			}
-			return nil
+			return thresholdErr
 		},
 	}
 	deprecatedCmd.Flags().String("from", "", "Required: The version you are migrating from. ")
@@ -36,6 +39,8 @@ corefile-tool deprecated --from 1.4.0 --to 1.5.0 --corefile /path/to/Corefile`,
 	deprecatedCmd.MarkFlagRequired("to")
 	deprecatedCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
 	deprecatedCmd.MarkFlagRequired("corefile")
+	addSeverityFlags(deprecatedCmd)
+	addNearestPatchFlag(deprecatedCmd)
 
 	return deprecatedCmd
 }
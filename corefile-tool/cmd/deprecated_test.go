@@ -59,9 +59,9 @@ func TestNewDeprecatedCmd(t *testing.T) {
     loadbalance
 }
 `,
-			expectedOutput: `Option "upstream" in plugin "kubernetes" is ignored in 1.5.0.
-Plugin "proxy" is removed in 1.5.0. It is replaced by "forward".
-Plugin "ready" is added as a default in 1.5.0.
+			expectedOutput: `Option "upstream" in plugin "kubernetes" in server block ".:53" is ignored in 1.5.0.
+Plugin "proxy" in server block ".:53" is removed in 1.5.0. It is replaced by "forward".
+Plugin "ready" in server block ".:53" is added as a default in 1.5.0.
 `,
 			expectedError: false,
 		},
@@ -107,3 +107,147 @@ Plugin "ready" is added as a default in 1.5.0.
 		})
 	}
 }
+
+func TestNewDeprecatedCmdConsolidated(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+
+	corefile := `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+
+	var buf bytes.Buffer
+	cmd := NewDeprecatedCmd(&buf)
+	cmd.SetArgs([]string{"--from", "1.3.1", "--to", "1.7.0", "--corefile", corefilePath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Cannot execute command: %v", err)
+	}
+
+	expectedOutput := `Option "upstream" in plugin "kubernetes" in server block ".:53" is deprecated in 1.4.0, ignored in 1.5.0, removed in 1.7.0.
+Plugin "proxy" in server block ".:53" is deprecated in 1.4.0, removed in 1.5.0. It is replaced by "forward".
+Plugin "ready" in server block ".:53" is added as a default in 1.5.0.
+Option "lameduck" in plugin "health" in server block ".:53" is added as a default in 1.6.5.
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("Expected output %v did not match %v", buf.String(), expectedOutput)
+	}
+}
+
+func TestNewDeprecatedCmdSeverity(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+
+	corefile := `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+
+	testCases := []struct {
+		name             string
+		args             []string
+		expectedOutput   string
+		expectedExitCode int
+	}{
+		{
+			name: "only removals",
+			args: []string{"--severity", "removed"},
+			expectedOutput: `Plugin "proxy" in server block ".:53" is removed in 1.5.0. It is replaced by "forward".
+Option "upstream" in plugin "kubernetes" in server block ".:53" is removed in 1.7.0.
+`,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name: "fail on removals",
+			args: []string{"--severity", "ignored", "--fail-on", "removed"},
+			expectedOutput: `Option "upstream" in plugin "kubernetes" in server block ".:53" is ignored in 1.5.0, removed in 1.7.0.
+Plugin "proxy" in server block ".:53" is removed in 1.5.0. It is replaced by "forward".
+`,
+			expectedExitCode: exitCodeThreshold,
+		},
+		{
+			name:             "fail on unsupported",
+			args:             []string{"--severity", "unsupported", "--fail-on", "unsupported"},
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "unknown patch release",
+			args:             []string{"--to", "1.7.2", "--severity", "removed"},
+			expectedExitCode: exitCodeError,
+		},
+		{
+			name: "nearest patch release",
+			args: []string{"--to", "1.7.2", "--severity", "removed", "--nearest-patch"},
+			expectedOutput: `Plugin "proxy" in server block ".:53" is removed in 1.5.0. It is replaced by "forward".
+Option "upstream" in plugin "kubernetes" in server block ".:53" is removed in 1.7.0.
+`,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "invalid severity",
+			args:             []string{"--severity", "bad"},
+			expectedExitCode: exitCodeError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewDeprecatedCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs(append([]string{"--from", "1.3.1", "--to", "1.7.0", "--corefile", corefilePath}, tc.args...))
+
+			code := exitCodeOK
+			if err := cmd.Execute(); err != nil {
+				code = exitCodeError
+				if exitErr, ok := err.(*exitError); ok {
+					code = exitErr.code
+				}
+			}
+			if code != tc.expectedExitCode {
+				t.Errorf("Expected exit code %v, got %v", tc.expectedExitCode, code)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// NewFmtCmd represents the fmt command
+func NewFmtCmd(out io.Writer) *cobra.Command {
+	fmtCmd := &cobra.Command{
+		Use:   "fmt",
+		Short: "Formats your Corefile in canonical style, keeping comments",
+		Example: `# Print the formatted Corefile.
+corefile-tool fmt --corefile /path/to/Corefile
+
+# Format the Corefile in place, putting plugins in plugin.cfg order.
+corefile-tool fmt --corefile /path/to/Corefile --write --plugin-order
+
+# Fail if the Corefile is not formatted.
+corefile-tool fmt --corefile /path/to/Corefile --check`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corefile, _ := cmd.Flags().GetString("corefile")
+			check, _ := cmd.Flags().GetBool("check")
+			write, _ := cmd.Flags().GetBool("write")
+			pluginOrder, _ := cmd.Flags().GetBool("plugin-order")
+			if check && write {
+				return errors.New("--check and --write are mutually exclusive")
+			}
+
+			original, formatted, err := formatCorefileFromPath(corefile, migration.FormatOptions{SortPlugins: pluginOrder})
+			if err != nil {
+				return fmt.Errorf("error while formatting the Corefile: %v \n", err)
+			}
+			switch {
+			case check:
+				if formatted != original {
+					fmt.Fprintln(out, corefile)
+					// an unformatted Corefile is not a usage error
+					cmd.SilenceUsage = true
+					return &exitError{code: exitCodeThreshold, err: fmt.Errorf("Corefile %v is not formatted", corefile)}
+				}
+			case write:
+				if formatted == original {
+					return nil
+				}
+				info, err := os.Stat(corefile)
+				if err != nil {
+					return fmt.Errorf("error while writing the Corefile: %v \n", err)
+				}
+				if err := ioutil.WriteFile(corefile, []byte(formatted), info.Mode()); err != nil {
+					return fmt.Errorf("error while writing the Corefile: %v \n", err)
+				}
+			default:
+				fmt.Fprint(out, formatted)
+			}
+			return nil
+		},
+	}
+	fmtCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
+	fmtCmd.MarkFlagRequired("corefile")
+	fmtCmd.Flags().Bool("check", false, fmt.Sprintf("Print the path and exit with code %d if the Corefile is not formatted, instead of printing it.", exitCodeThreshold))
+	fmtCmd.Flags().Bool("write", false, "Write the formatted Corefile back to its file instead of printing it.")
+	fmtCmd.Flags().Bool("plugin-order", false, "Put the plugins of each server block in the order of CoreDNS's plugin.cfg.")
+
+	return fmtCmd
+}
+
+// formatCorefileFromPath takes the path where the Corefile is located and returns the Corefile as read and as
+// formatted.
+func formatCorefileFromPath(corefilePath string, opts migration.FormatOptions) (string, string, error) {
+	fileBytes, err := getCorefileFromPath(corefilePath)
+	if err != nil {
+		return "", "", err
+	}
+	formatted, err := migration.Format(string(fileBytes), opts)
+	if err != nil {
+		return "", "", err
+	}
+	return string(fileBytes), formatted, nil
+}
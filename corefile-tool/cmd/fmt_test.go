@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFmtCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	unformatted := `.:53 {
+  # forward to the node
+  forward . /etc/resolv.conf
+  errors   # log errors
+}`
+	formatted := `.:53 {
+    # forward to the node
+    forward . /etc/resolv.conf
+    errors # log errors
+}
+`
+	ordered := `.:53 {
+    errors # log errors
+    # forward to the node
+    forward . /etc/resolv.conf
+}
+`
+
+	testCases := []struct {
+		name             string
+		corefile         string
+		args             []string
+		expectedOutput   string
+		expectedCorefile string
+		expectedExitCode int
+	}{
+		{
+			name:             "print",
+			corefile:         unformatted,
+			expectedOutput:   formatted,
+			expectedCorefile: unformatted,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "print in plugin order",
+			corefile:         unformatted,
+			args:             []string{"--plugin-order"},
+			expectedOutput:   ordered,
+			expectedCorefile: unformatted,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "check unformatted",
+			corefile:         unformatted,
+			args:             []string{"--check"},
+			expectedOutput:   "CORE\n",
+			expectedCorefile: unformatted,
+			expectedExitCode: exitCodeThreshold,
+		},
+		{
+			name:             "check formatted",
+			corefile:         formatted,
+			args:             []string{"--check"},
+			expectedCorefile: formatted,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "check plugin order",
+			corefile:         formatted,
+			args:             []string{"--check", "--plugin-order"},
+			expectedOutput:   "CORE\n",
+			expectedCorefile: formatted,
+			expectedExitCode: exitCodeThreshold,
+		},
+		{
+			name:             "write",
+			corefile:         unformatted,
+			args:             []string{"--write"},
+			expectedCorefile: formatted,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name:             "check and write",
+			corefile:         unformatted,
+			args:             []string{"--check", "--write"},
+			expectedCorefile: unformatted,
+			expectedExitCode: exitCodeError,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			corefilePath := filepath.Join(tmpDir, fmt.Sprintf("test-corefile-%d", i))
+			if err := ioutil.WriteFile(corefilePath, []byte(tc.corefile), 0644); err != nil {
+				t.Fatalf("Unable to write test file %q: %v", corefilePath, err)
+			}
+
+			var buf bytes.Buffer
+			cmd := NewFmtCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs(append([]string{"--corefile", corefilePath}, tc.args...))
+
+			code := exitCodeOK
+			if err := cmd.Execute(); err != nil {
+				code = exitCodeError
+				if exitErr, ok := err.(*exitError); ok {
+					code = exitErr.code
+				}
+			}
+			if code != tc.expectedExitCode {
+				t.Errorf("Expected exit code %v, got %v", tc.expectedExitCode, code)
+			}
+			expectedOutput := bytes.Replace([]byte(tc.expectedOutput), []byte("CORE"), []byte(corefilePath), -1)
+			if buf.String() != string(expectedOutput) {
+				t.Errorf("Expected output %v did not match %v", string(expectedOutput), buf.String())
+			}
+			got, err := ioutil.ReadFile(corefilePath)
+			if err != nil {
+				t.Fatalf("Unable to read test file %q: %v", corefilePath, err)
+			}
+			if string(got) != tc.expectedCorefile {
+				t.Errorf("Expected Corefile %v did not match %v", tc.expectedCorefile, string(got))
+			}
+		})
+	}
+}
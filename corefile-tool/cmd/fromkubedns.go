@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/deployment"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NewFromKubeDNSCmd represents the from-kubedns command
+func NewFromKubeDNSCmd(out io.Writer) *cobra.Command {
+	fromKubeDNSCmd := &cobra.Command{
+		Use:   "from-kubedns",
+		Short: "Converts a kube-dns ConfigMap to a CoreDNS Corefile",
+		Example: `# Convert the kube-dns ConfigMap to a Corefile for CoreDNS v1.11.1.
+kubectl -n kube-system get configmap kube-dns -o yaml > kube-dns.yaml
+corefile-tool from-kubedns --configmap kube-dns.yaml --to 1.11.1
+
+# Take the cluster domain from the --domain flag of the kube-dns Deployment.
+kubectl -n kube-system get deployment kube-dns -o yaml > kube-dns-deployment.yaml
+corefile-tool from-kubedns --configmap kube-dns.yaml --deployment kube-dns-deployment.yaml --to 1.11.1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configMap, _ := cmd.Flags().GetString("configmap")
+			to, _ := cmd.Flags().GetString("to")
+			clusterDomain, _ := cmd.Flags().GetString("cluster-domain")
+			deploymentPath, _ := cmd.Flags().GetString("deployment")
+
+			if deploymentPath != "" && !cmd.Flags().Changed("cluster-domain") {
+				var err error
+				clusterDomain, err = clusterDomainFromPath(deploymentPath)
+				if err != nil {
+					return fmt.Errorf("error while reading the kube-dns Deployment: %v \n", err)
+				}
+			}
+			corefile, err := fromKubeDNSFromPath(configMap, to, migration.KubeDNSOptions{ClusterDomain: clusterDomain})
+			if err != nil {
+				return fmt.Errorf("error while converting the kube-dns ConfigMap: %v \n", err)
+			}
+			fmt.Fprint(out, corefile)
+			return nil
+		},
+	}
+	fromKubeDNSCmd.Flags().String("configmap", "", "Required: The path of the kube-dns ConfigMap, in YAML or JSON.")
+	fromKubeDNSCmd.MarkFlagRequired("configmap")
+	fromKubeDNSCmd.Flags().String("to", "", "Required: The CoreDNS version to generate the Corefile for.")
+	fromKubeDNSCmd.MarkFlagRequired("to")
+	fromKubeDNSCmd.Flags().String("cluster-domain", "cluster.local", "The cluster domain served by kube-dns.")
+	fromKubeDNSCmd.Flags().String("deployment", "", "The path of the kube-dns Deployment, to take the cluster domain from the --domain flag of kube-dns. --cluster-domain takes precedence.")
+
+	return fromKubeDNSCmd
+}
+
+// fromKubeDNSFromPath takes the path where the kube-dns ConfigMap is located and returns the equivalent Corefile for
+// the CoreDNS version.
+func fromKubeDNSFromPath(configMapPath, toCoreDNSVersion string, opts migration.KubeDNSOptions) (string, error) {
+	fileBytes, err := ioutil.ReadFile(configMapPath)
+	if err != nil {
+		return "", err
+	}
+	var configMap struct {
+		Kind string            `yaml:"kind"`
+		Data map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(fileBytes, &configMap); err != nil {
+		return "", err
+	}
+	if configMap.Kind != "ConfigMap" {
+		return "", fmt.Errorf("expected a ConfigMap, got kind '%v'", configMap.Kind)
+	}
+	return migration.FromKubeDNSWithOptions(configMap.Data, toCoreDNSVersion, opts)
+}
+
+// clusterDomainFromPath takes the path where the kube-dns Deployment is located and returns the cluster domain set by
+// the --domain flag of its containers, or "cluster.local" if none sets it.
+func clusterDomainFromPath(deploymentPath string) (string, error) {
+	fileBytes, err := ioutil.ReadFile(deploymentPath)
+	if err != nil {
+		return "", err
+	}
+	m, err := deployment.Parse(fileBytes)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range m.Containers {
+		if domain := migration.KubeDNSClusterDomain(c.Args); domain != "" {
+			return domain, nil
+		}
+	}
+	return "cluster.local", nil
+}
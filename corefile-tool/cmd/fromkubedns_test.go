@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFromKubeDNSCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	configMapPath := filepath.Join(tmpDir, "kube-dns.yaml")
+	deploymentPath := filepath.Join(tmpDir, "deployment.yaml")
+	kubeDNSPath := filepath.Join(tmpDir, "kube-dns-deployment.yaml")
+
+	configMap := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-dns
+  namespace: kube-system
+data:
+  stubDomains: |
+    {"acme.local": ["1.2.3.4"]}
+  upstreamNameservers: |
+    ["8.8.8.8"]
+`
+	if err := ioutil.WriteFile(configMapPath, []byte(configMap), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", configMapPath, err)
+	}
+	if err := ioutil.WriteFile(deploymentPath, []byte("apiVersion: apps/v1\nkind: Deployment\n"), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", deploymentPath, err)
+	}
+
+	kubeDNS := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kube-dns
+spec:
+  template:
+    spec:
+      containers:
+      - name: kubedns
+        image: registry.k8s.io/dns/k8s-dns-kube-dns:1.22.20
+        args:
+        - --domain=corp.example.
+        - --dns-port=10053
+      - name: dnsmasq
+        image: registry.k8s.io/dns/k8s-dns-dnsmasq-nanny:1.22.20
+`
+	if err := ioutil.WriteFile(kubeDNSPath, []byte(kubeDNS), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", kubeDNSPath, err)
+	}
+	corpCorefile := `.:53 {
+    errors
+    health
+    kubernetes corp.example in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . 8.8.8.8
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+acme.local:53 {
+    errors
+    cache 30
+    loop
+    forward . 1.2.3.4
+}
+`
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name: "convert",
+			args: []string{"--configmap", configMapPath, "--to", "1.3.1"},
+			expectedOutput: `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . 8.8.8.8
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+acme.local:53 {
+    errors
+    cache 30
+    loop
+    forward . 1.2.3.4
+}
+`,
+		},
+		{
+			name:           "cluster domain",
+			args:           []string{"--configmap", configMapPath, "--to", "1.3.1", "--cluster-domain", "corp.example"},
+			expectedOutput: corpCorefile,
+		},
+		{
+			name:           "cluster domain of the kube-dns Deployment",
+			args:           []string{"--configmap", configMapPath, "--to", "1.3.1", "--deployment", kubeDNSPath},
+			expectedOutput: corpCorefile,
+		},
+		{
+			name:          "not a Deployment",
+			args:          []string{"--configmap", configMapPath, "--to", "1.3.1", "--deployment", configMapPath},
+			expectedError: true,
+		},
+		{
+			name:          "not a ConfigMap",
+			args:          []string{"--configmap", deploymentPath, "--to", "1.3.1"},
+			expectedError: true,
+		},
+		{
+			name:          "missing version",
+			args:          []string{"--configmap", configMapPath},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewFromKubeDNSCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", tc.expectedOutput, buf.String())
+			}
+		})
+	}
+}
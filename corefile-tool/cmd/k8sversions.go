@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// NewK8sVersionsCmd represents the k8s-versions command
+func NewK8sVersionsCmd(out io.Writer) *cobra.Command {
+	k8sVersionsCmd := &cobra.Command{
+		Use:   "k8s-versions",
+		Short: "Shows which CoreDNS version each Kubernetes release deploys by default",
+		Example: `# Print the CoreDNS version deployed by default with Kubernetes v1.29.
+corefile-tool k8s-versions --k8sversion 1.29
+
+# Print the Kubernetes releases deploying CoreDNS v1.11.1 by default.
+corefile-tool k8s-versions --coredns-version 1.11.1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			k8sversion, _ := cmd.Flags().GetString("k8sversion")
+			corednsVersion, _ := cmd.Flags().GetString("coredns-version")
+
+			switch {
+			case k8sversion != "" && corednsVersion != "":
+				return fmt.Errorf("only one of --k8sversion and --coredns-version can be set")
+			case k8sversion != "":
+				v, err := migration.CoreDNSVersionForKubernetes(k8sversion)
+				if err != nil {
+					return fmt.Errorf("error while looking up the CoreDNS version: %v \n", err)
+				}
+				fmt.Fprintln(out, v)
+			case corednsVersion != "":
+				releases, err := migration.KubernetesReleasesFor(corednsVersion)
+				if err != nil {
+					return fmt.Errorf("error while looking up the Kubernetes releases: %v \n", err)
+				}
+				fmt.Fprintln(out, strings.Join(releases, ", "))
+			default:
+				fmt.Fprintln(out, "Kubernetes\tCoreDNS")
+				for _, k8s := range migration.KubernetesReleases() {
+					v, err := migration.CoreDNSVersionForKubernetes(k8s)
+					if err != nil {
+						return fmt.Errorf("error while looking up the CoreDNS version: %v \n", err)
+					}
+					fmt.Fprintf(out, "%v\t%v\n", k8s, v)
+				}
+			}
+			return nil
+		},
+	}
+	k8sVersionsCmd.Flags().String("k8sversion", "", "The Kubernetes version to print the default CoreDNS version for.")
+	k8sVersionsCmd.Flags().String("coredns-version", "", "The CoreDNS version to print the Kubernetes releases for.")
+
+	return k8sVersionsCmd
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewK8sVersionsCmd(t *testing.T) {
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name: "CoreDNS version for a Kubernetes release",
+			flags: map[string]string{
+				"k8sversion": "v1.29.3",
+			},
+			expectedOutput: "1.11.1\n",
+		},
+		{
+			name: "Kubernetes releases for a CoreDNS version",
+			flags: map[string]string{
+				"coredns-version": "1.11.3",
+			},
+			expectedOutput: "1.31, 1.32\n",
+		},
+		{
+			name: "unknown Kubernetes release",
+			flags: map[string]string{
+				"k8sversion": "1.99",
+			},
+			expectedError: true,
+		},
+		{
+			name: "both flags set",
+			flags: map[string]string{
+				"k8sversion":      "1.29",
+				"coredns-version": "1.11.1",
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewK8sVersionsCmd(&buf)
+
+			// Silence the usage and errors output when testing expected errors.
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+				return
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+
+	t.Run("full table", func(t *testing.T) {
+		var buf bytes.Buffer
+		cmd := NewK8sVersionsCmd(&buf)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Cannot execute command: %v", err)
+		}
+		if !strings.HasPrefix(buf.String(), "Kubernetes\tCoreDNS\n1.11\t1.1.3\n") || !strings.HasSuffix(buf.String(), "1.34\t1.12.1\n") {
+			t.Errorf("Unexpected output %v", buf.String())
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration/lint"
+
+	"github.com/spf13/cobra"
+)
+
+// NewLintCmd represents the lint command
+func NewLintCmd(out io.Writer) *cobra.Command {
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Checks your Corefile for common misconfigurations",
+		Example: `# Check the Corefile, failing on errors.
+corefile-tool lint --corefile /path/to/Corefile --fail-on error`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corefile, _ := cmd.Flags().GetString("corefile")
+			disable, _ := cmd.Flags().GetStringSlice("disable")
+			failOn, _ := cmd.Flags().GetString("fail-on")
+
+			var threshold lint.Severity
+			if failOn != "" {
+				var err error
+				threshold, err = lint.ParseSeverity(failOn)
+				if err != nil {
+					return err
+				}
+			}
+			findings, err := lintCorefileFromPath(corefile, disable)
+			if err != nil {
+				return fmt.Errorf("error while linting the Corefile: %v \n", err)
+			}
+			failed := false
+			for _, f := range findings {
+				fmt.Fprintln(out, f.ToString())
+				if threshold != "" && f.Severity.AtLeast(threshold) {
+					failed = true
+				}
+			}
+			if failed {
+				// the threshold being met is not a usage error
+				cmd.SilenceUsage = true
+				return &exitError{code: exitCodeThreshold, err: fmt.Errorf("found findings of severity %v or higher", threshold)}
+			}
+			return nil
+		},
+	}
+	lintCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
+	lintCmd.MarkFlagRequired("corefile")
+	lintCmd.Flags().StringSlice("disable", nil, "Rules to disable, e.g. health-without-lameduck.")
+	lintCmd.Flags().String("fail-on", "", fmt.Sprintf("Exit with code %d if any finding of this severity or higher is found. [info | warning | error]", exitCodeThreshold))
+
+	return lintCmd
+}
+
+// lintCorefileFromPath takes the path where the Corefile is located and returns the findings of the default rules,
+// except the disabled ones.
+func lintCorefileFromPath(corefilePath string, disable []string) ([]lint.Finding, error) {
+	fileBytes, err := getCorefileFromPath(corefilePath)
+	if err != nil {
+		return nil, err
+	}
+	rules := []lint.Rule{}
+	disabled := map[string]bool{}
+	for _, name := range disable {
+		disabled[strings.TrimSpace(name)] = true
+	}
+	for _, r := range lint.DefaultRules() {
+		if disabled[r.Name()] {
+			delete(disabled, r.Name())
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if len(disabled) > 0 {
+		unknown := []string{}
+		for name := range disabled {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown rules: %v", strings.Join(unknown, ", "))
+	}
+	return lint.LintString(string(fileBytes), rules)
+}
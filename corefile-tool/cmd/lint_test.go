@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewLintCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+
+	corefile := `.:53 {
+    health
+    forward . /etc/resolv.conf
+    cache 30
+    log
+    log
+}
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+
+	testCases := []struct {
+		name             string
+		args             []string
+		expectedOutput   string
+		expectedExitCode int
+	}{
+		{
+			name: "all rules",
+			args: []string{"--corefile", corefilePath},
+			expectedOutput: `[warning] forward-without-loop: server block ".:53", plugin "forward": forwarding without the loop plugin, forwarding loops will not be detected
+[warning] health-without-lameduck: server block ".:53", plugin "health": no lameduck duration set, queries may fail while CoreDNS shuts down
+[error] duplicate-plugin: server block ".:53", plugin "log": plugin appears more than once in the server block
+`,
+			expectedExitCode: exitCodeOK,
+		},
+		{
+			name: "disabled rules and fail on error",
+			args: []string{"--corefile", corefilePath, "--disable", "forward-without-loop,health-without-lameduck", "--fail-on", "error"},
+			expectedOutput: `[error] duplicate-plugin: server block ".:53", plugin "log": plugin appears more than once in the server block
+`,
+			expectedExitCode: exitCodeThreshold,
+		},
+		{
+			name:             "unknown rule",
+			args:             []string{"--corefile", corefilePath, "--disable", "no-such-rule"},
+			expectedExitCode: exitCodeError,
+		},
+		{
+			name:             "missing corefile",
+			args:             []string{},
+			expectedExitCode: exitCodeError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewLintCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs(tc.args)
+
+			code := exitCodeOK
+			if err := cmd.Execute(); err != nil {
+				code = exitCodeError
+				if exitErr, ok := err.(*exitError); ok {
+					code = exitErr.code
+				}
+			}
+			if code != tc.expectedExitCode {
+				t.Errorf("Expected exit code %v, got %v", tc.expectedExitCode, code)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
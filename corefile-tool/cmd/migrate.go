@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 
 	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/helm"
 
 	"github.com/spf13/cobra"
 )
@@ -18,14 +22,59 @@ func NewMigrateCmd(out io.Writer) *cobra.Command {
 corefile-tool migrate --from 1.4.0 --to 1.5.0 --corefile /path/to/Corefile  --deprecations true
 
 # Migrate CoreDNS from v1.2.2 to v1.3.1 and do not handle deprecations .
-corefile-tool migrate --from 1.2.2 --to 1.3.1 --corefile /path/to/Corefile  --deprecations false`,
+corefile-tool migrate --from 1.2.2 --to 1.3.1 --corefile /path/to/Corefile  --deprecations false
+
+# Migrate CoreDNS from v1.3.1 to v1.11.1, failing if anything unsupported or removed is present.
+corefile-tool migrate --from 1.3.1 --to 1.11.1 --corefile /path/to/Corefile --unknown-plugins error --unknown-options error --abort-on removed
+
+# Migrate the servers of the CoreDNS Helm chart values from v1.8.6 to v1.11.1.
+corefile-tool migrate --from 1.8.6 --to 1.11.1 --helm-values /path/to/values.yaml > values-1.11.1.yaml
+
+# Migrate the Corefiles of all clusters from v1.8.6 to v1.11.1, 8 at a time, into the migrated directory.
+corefile-tool migrate --from 1.8.6 --to 1.11.1 --batch 'clusters/*/Corefile' --workers 8 --output-dir migrated
+
+# Migrate the Corefiles listed by a manifest of path, from and to entries, beside each Corefile.
+corefile-tool migrate --to 1.11.1 --batch clusters.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			from, _ := cmd.Flags().GetString("from")
-			to, _ := cmd.Flags().GetString("to")
+			from, to, err := versionsFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error while migration: %v \n", err)
+			}
 			corefile, _ := cmd.Flags().GetString("corefile")
-			deprecations, _ := cmd.Flags().GetBool("deprecations")
+			helmValues, _ := cmd.Flags().GetString("helm-values")
+			batch, _ := cmd.Flags().GetString("batch")
+			if countSet(corefile, helmValues, batch) != 1 {
+				return errors.New("exactly one of --corefile, --helm-values or --batch is required")
+			}
+			opts, err := migrateOptionsFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if batch != "" {
+				outputDir, _ := cmd.Flags().GetString("output-dir")
+				workers, _ := cmd.Flags().GetInt("workers")
+				return migrateBatch(out, batch, from, to, versionResolverFromFlags(cmd), outputDir, workers, opts)
+			}
+			if from == "" || to == "" {
+				return errors.New("--from and --to are required")
+			}
 
-			migrated, err := migrateCorefileFromPath(from, to, corefile, deprecations)
+			var migrated string
+			if helmValues != "" {
+				migrated, err = migrateHelmValuesFromPath(from, to, helmValues, opts)
+			} else {
+				migrated, err = migrateCorefileFromPath(from, to, corefile, opts)
+			}
+			var errs migration.MigrationErrors
+			if errors.As(err, &errs) {
+				// print the partially migrated Corefile along with every problem found
+				fmt.Fprintln(out, migrated)
+				msgs := []string{}
+				for _, e := range errs {
+					msgs = append(msgs, e.Error())
+				}
+				return fmt.Errorf("migration completed with errors:\n%v", strings.Join(msgs, "\n"))
+			}
 			if err != nil {
 				return fmt.Errorf("error while migration: %v \n", err)
 			}
@@ -33,24 +82,94 @@ corefile-tool migrate --from 1.2.2 --to 1.3.1 --corefile /path/to/Corefile  --de
 			return nil
 		},
 	}
-	migrateCmd.Flags().String("from", "", "Required: The version you are migrating from. ")
-	migrateCmd.MarkFlagRequired("from")
-	migrateCmd.Flags().String("to", "", "Required: The version you are migrating to.")
-	migrateCmd.MarkFlagRequired("to")
-	migrateCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
-	migrateCmd.MarkFlagRequired("corefile")
+	migrateCmd.Flags().String("from", "", "Required: The version you are migrating from. With a --batch manifest, the default for entries without one.")
+	migrateCmd.Flags().String("to", "", "Required: The version you are migrating to. With a --batch manifest, the default for entries without one.")
+	migrateCmd.Flags().String("corefile", "", "The path where your Corefile is located. Required unless --helm-values or --batch is set.")
+	migrateCmd.Flags().String("helm-values", "", "The path of CoreDNS Helm chart values to migrate the servers of, instead of a Corefile. The migrated values are printed with everything else preserved.")
 	migrateCmd.Flags().Bool("deprecations", false, "Specify whether you want to handle plugin deprecations. [True | False] ")
+	migrateCmd.Flags().String("unknown-plugins", "keep", "Specify how plugins unsupported by the tool are handled. [keep | error]")
+	migrateCmd.Flags().String("unknown-options", "keep", "Specify how plugin options unsupported by the tool are handled. [keep | error]")
+	migrateCmd.Flags().Bool("new-defaults", true, "Specify whether new default plugins/options are added. [True | False]")
+	migrateCmd.Flags().Bool("split-server-blocks", true, "Specify whether plugins may be split out into new server blocks. [True | False]")
+	migrateCmd.Flags().Bool("continue-on-error", false, "Keep migrating everything possible, leaving failed server blocks/plugins untouched, and report all errors. [True | False]")
+	migrateCmd.Flags().String("batch", "", "A glob of Corefiles, or a YAML manifest (.yaml/.yml) listing the path, from and to versions of each Corefile, to migrate concurrently. A summary of the batch is printed.")
+	migrateCmd.Flags().String("output-dir", "", "With --batch, the directory migrated Corefiles are written into, under their own path. By default, they are written beside each Corefile, with the version migrated to appended to their name.")
+	migrateCmd.Flags().Int("workers", 0, "With --batch, the number of Corefiles migrated at once. Defaults to the number of CPUs.")
+	addNearestPatchFlag(migrateCmd)
+	migrateCmd.Flags().String("abort-on", "", "Abort the migration if any notice of this severity or higher is raised. [newdefault | deprecated | ignored | removed | unsupported]")
 
 	return migrateCmd
 }
 
+// migrateOptionsFromFlags maps the migrate command flags onto the migration options.
+func migrateOptionsFromFlags(cmd *cobra.Command) (migration.MigrateOptions, error) {
+	var err error
+	opts := migration.MigrateOptions{}
+	opts.Deprecations, _ = cmd.Flags().GetBool("deprecations")
+	unknownPlugins, _ := cmd.Flags().GetString("unknown-plugins")
+	opts.UnknownPlugins, err = parseUnknownPolicy(unknownPlugins)
+	if err != nil {
+		return opts, err
+	}
+	unknownOptions, _ := cmd.Flags().GetString("unknown-options")
+	opts.UnknownOptions, err = parseUnknownPolicy(unknownOptions)
+	if err != nil {
+		return opts, err
+	}
+	newDefaults, _ := cmd.Flags().GetBool("new-defaults")
+	opts.SkipNewDefaults = !newDefaults
+	split, _ := cmd.Flags().GetBool("split-server-blocks")
+	opts.NoServerBlockSplit = !split
+	opts.ContinueOnError, _ = cmd.Flags().GetBool("continue-on-error")
+	abortOn, _ := cmd.Flags().GetString("abort-on")
+	if abortOn != "" {
+		opts.AbortSeverity, err = migration.ParseSeverity(abortOn)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// countSet returns the number of non empty values.
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func parseUnknownPolicy(policy string) (migration.UnknownPolicy, error) {
+	switch policy {
+	case "keep":
+		return migration.UnknownKeep, nil
+	case "error":
+		return migration.UnknownError, nil
+	}
+	return migration.UnknownKeep, fmt.Errorf("invalid unknown plugin/option policy '%v'", policy)
+}
+
 // migrateCorefileFromPath takes the path where the Corefile is located and migrates the Corefile to the
 // desrired version.
-func migrateCorefileFromPath(fromCoreDNSVersion, toCoreDNSVersion, corefilePath string, deprecations bool) (string, error) {
+func migrateCorefileFromPath(fromCoreDNSVersion, toCoreDNSVersion, corefilePath string, opts migration.MigrateOptions) (string, error) {
 	fileBytes, err := getCorefileFromPath(corefilePath)
 	if err != nil {
 		return "", err
 	}
 	corefileStr := string(fileBytes)
-	return migration.Migrate(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, deprecations)
+	return migration.MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, opts)
+}
+
+// migrateHelmValuesFromPath takes the path where the CoreDNS Helm chart values are located and migrates their servers
+// to the desired version.
+func migrateHelmValuesFromPath(fromCoreDNSVersion, toCoreDNSVersion, valuesPath string, opts migration.MigrateOptions) (string, error) {
+	valuesBytes, err := ioutil.ReadFile(valuesPath)
+	if err != nil {
+		return "", err
+	}
+	migrated, err := helm.MigrateValues(fromCoreDNSVersion, toCoreDNSVersion, valuesBytes, opts)
+	return strings.TrimSuffix(string(migrated), "\n"), err
 }
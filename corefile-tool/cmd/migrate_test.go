@@ -120,3 +120,252 @@ func TestNewMigrateCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMigrateCmdOptions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+
+	corefile := `.:53 {
+    health
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+}
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name: "best effort",
+			flags: map[string]string{
+				"from":     "1.6.2",
+				"to":       "1.7.0",
+				"corefile": corefilePath,
+			},
+			expectedOutput: `.:53 {
+    health {
+        lameduck 5s
+    }
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+}
+
+`,
+		},
+		{
+			name: "no new defaults",
+			flags: map[string]string{
+				"from":         "1.6.2",
+				"to":           "1.7.0",
+				"corefile":     corefilePath,
+				"new-defaults": "false",
+			},
+			expectedOutput: corefile + "\n",
+		},
+		{
+			name: "fail on unknown plugins",
+			flags: map[string]string{
+				"from":            "1.6.2",
+				"to":              "1.7.0",
+				"corefile":        corefilePath,
+				"unknown-plugins": "error",
+			},
+			expectedError: true,
+		},
+		{
+			name: "fail on unsupported notices",
+			flags: map[string]string{
+				"from":     "1.6.2",
+				"to":       "1.7.0",
+				"corefile": corefilePath,
+				"abort-on": "unsupported",
+			},
+			expectedError: true,
+		},
+		{
+			name: "continue on error",
+			flags: map[string]string{
+				"from":              "1.6.2",
+				"to":                "1.7.0",
+				"corefile":          corefilePath,
+				"unknown-plugins":   "error",
+				"continue-on-error": "true",
+			},
+			expectedOutput: `.:53 {
+    health {
+        lameduck 5s
+    }
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+}
+
+`,
+			expectedError: true,
+		},
+		{
+			name: "unknown patch release",
+			flags: map[string]string{
+				"from":     "1.6.2",
+				"to":       "1.7.2",
+				"corefile": corefilePath,
+			},
+			expectedError: true,
+		},
+		{
+			name: "nearest patch release",
+			flags: map[string]string{
+				"from":          "1.6.2",
+				"to":            "1.7.2",
+				"corefile":      corefilePath,
+				"nearest-patch": "true",
+			},
+			expectedOutput: `.:53 {
+    health {
+        lameduck 5s
+    }
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+}
+
+`,
+		},
+		{
+			name: "invalid policy",
+			flags: map[string]string{
+				"from":            "1.6.2",
+				"to":              "1.7.0",
+				"corefile":        corefilePath,
+				"unknown-options": "banana",
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewMigrateCmd(&buf)
+
+			// Silence the usage and errors output when testing expected errors.
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", tc.expectedOutput, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewMigrateCmdHelmValues(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+
+	values := `replicaCount: 2
+servers:
+  - zones:
+      - zone: .
+    port: 53
+    plugins:
+      - name: errors
+      - name: proxy
+        parameters: . /etc/resolv.conf
+`
+	if err := ioutil.WriteFile(valuesPath, []byte(values), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", valuesPath, err)
+	}
+
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name: "helm values",
+			flags: map[string]string{
+				"from":         "1.3.1",
+				"to":           "1.5.0",
+				"helm-values":  valuesPath,
+				"new-defaults": "false",
+			},
+			expectedOutput: `replicaCount: 2
+servers:
+  - zones:
+      - zone: .
+    port: 53
+    plugins:
+      - name: errors
+      - name: forward
+        parameters: . /etc/resolv.conf
+`,
+		},
+		{
+			name: "corefile and helm values",
+			flags: map[string]string{
+				"from":        "1.3.1",
+				"to":          "1.5.0",
+				"helm-values": valuesPath,
+				"corefile":    valuesPath,
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewMigrateCmd(&buf)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", tc.expectedOutput, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// NewNormalizeCmd represents the normalize command
+func NewNormalizeCmd(out io.Writer) *cobra.Command {
+	normalizeCmd := &cobra.Command{
+		Use:   "normalize",
+		Short: "Resolves a CoreDNS version string to a version supported by the tool",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, _ := cmd.Flags().GetString("version")
+			resolved, err := versionResolverFromFlags(cmd)(version)
+			if err != nil {
+				return fmt.Errorf("error while normalizing version: %v \n", err)
+			}
+			fmt.Fprintln(out, resolved)
+			return nil
+		},
+	}
+
+	normalizeCmd.Flags().String("version", "", "Required: The CoreDNS version to normalize, e.g. v1.11.1 or 1.11.1-eks.1. ")
+	addNearestPatchFlag(normalizeCmd)
+	normalizeCmd.MarkFlagRequired("version")
+
+	return normalizeCmd
+}
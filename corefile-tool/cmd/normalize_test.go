@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewNormalizeCmd(t *testing.T) {
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name:          "fails if no flags set",
+			expectedError: true,
+		},
+		{
+			name: "v prefix and vendor suffix",
+			flags: map[string]string{
+				"version": "v1.11.1-eks.1",
+			},
+			expectedOutput: "1.11.1\n",
+		},
+		{
+			name: "unknown patch release",
+			flags: map[string]string{
+				"version": "1.11.2",
+			},
+			expectedError: true,
+		},
+		{
+			name: "unknown patch release resolved to nearest",
+			flags: map[string]string{
+				"version":       "1.11.2",
+				"nearest-patch": "true",
+			},
+			expectedOutput: "1.11.1\n",
+		},
+		{
+			name: "invalid version",
+			flags: map[string]string{
+				"version": "banana",
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewNormalizeCmd(&buf)
+
+			// Silence the usage and errors output when testing expected errors.
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+				return
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPlanCmd represents the plan command
+func NewPlanCmd(out io.Writer) *cobra.Command {
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Shows each intermediate step of migrating your CoreDNS corefile",
+		Example: `# Preview the migration of CoreDNS from v1.2.6 to v1.11.1, step by step.
+corefile-tool plan --from 1.2.6 --to 1.11.1 --corefile /path/to/Corefile`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := versionsFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error while planning the migration: %v \n", err)
+			}
+			corefile, _ := cmd.Flags().GetString("corefile")
+			deprecations, _ := cmd.Flags().GetBool("deprecations")
+			continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+
+			plan, err := planCorefileFromPath(from, to, corefile, migration.MigrateOptions{
+				Deprecations:    deprecations,
+				ContinueOnError: continueOnError,
+			})
+			if err != nil {
+				return fmt.Errorf("error while planning the migration: %v \n", err)
+			}
+			printPlan(out, plan)
+			return nil
+		},
+	}
+	planCmd.Flags().String("from", "", "Required: The version you are migrating from. ")
+	planCmd.MarkFlagRequired("from")
+	planCmd.Flags().String("to", "", "Required: The version you are migrating to.")
+	planCmd.MarkFlagRequired("to")
+	planCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
+	planCmd.MarkFlagRequired("corefile")
+	planCmd.Flags().Bool("deprecations", false, "Specify whether you want to handle plugin deprecations. [True | False] ")
+	planCmd.Flags().Bool("continue-on-error", false, "Keep planning past errors, leaving failed server blocks/plugins untouched, and report all errors. [True | False]")
+	addNearestPatchFlag(planCmd)
+
+	return planCmd
+}
+
+// printPlan writes the plan in a human readable form.  The Corefile is only printed for steps that change it.
+func printPlan(out io.Writer, plan *migration.MigrationPlan) {
+	fmt.Fprintf(out, "Migration plan from %v to %v:\n", plan.From, plan.To)
+	for i, s := range plan.Steps {
+		fmt.Fprintf(out, "\nStep %d: %v -> %v\n", i+1, s.From, s.Version)
+		if s.PreProcess {
+			fmt.Fprintln(out, "  Corefile-wide rewrite before migrating plugins")
+		}
+		if s.PostProcess {
+			fmt.Fprintln(out, "  Corefile-wide rewrite after migrating plugins")
+		}
+		if len(s.Notices) > 0 {
+			fmt.Fprintln(out, "  Notices:")
+			for _, n := range s.Notices {
+				fmt.Fprintf(out, "    %v\n", n.ToString())
+			}
+		}
+		if len(s.Errors) > 0 {
+			fmt.Fprintln(out, "  Errors:")
+			for _, e := range s.Errors {
+				fmt.Fprintf(out, "    %v\n", e.Error())
+			}
+		}
+		if !s.Changed {
+			fmt.Fprintln(out, "  Corefile unchanged")
+			continue
+		}
+		fmt.Fprintln(out, "  Corefile:")
+		for _, line := range strings.Split(strings.TrimRight(s.Corefile, "\n"), "\n") {
+			fmt.Fprintf(out, "    %v\n", line)
+		}
+	}
+}
+
+// planCorefileFromPath takes the path where the Corefile is located and plans the migration of the Corefile to the
+// desired version.
+func planCorefileFromPath(fromCoreDNSVersion, toCoreDNSVersion, corefilePath string, opts migration.MigrateOptions) (*migration.MigrationPlan, error) {
+	fileBytes, err := getCorefileFromPath(corefilePath)
+	if err != nil {
+		return nil, err
+	}
+	return migration.PlanWithOptions(fromCoreDNSVersion, toCoreDNSVersion, string(fileBytes), opts)
+}
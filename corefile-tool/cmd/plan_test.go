@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewPlanCmd(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "corefile")
+	if err != nil {
+		t.Errorf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	corefilePath := filepath.Join(tmpDir, "test-corefile")
+
+	corefile := `.:53 {
+    health
+    forward . /etc/resolv.conf
+}
+`
+	if err := ioutil.WriteFile(corefilePath, []byte(corefile), 0644); err != nil {
+		t.Errorf("Unable to write test file %q: %v", corefilePath, err)
+	}
+
+	testCases := []struct {
+		name           string
+		flags          map[string]string
+		expectedOutput string
+		expectedError  bool
+	}{
+		{
+			name: "plan",
+			flags: map[string]string{
+				"from":     "1.6.4",
+				"to":       "1.6.6",
+				"corefile": corefilePath,
+			},
+			expectedOutput: `Migration plan from 1.6.4 to 1.6.6:
+
+Step 1: 1.6.4 -> 1.6.5
+  Notices:
+    Option "lameduck" in plugin "health" is added as a default in 1.6.5.
+  Corefile:
+    .:53 {
+        health {
+            lameduck 5s
+        }
+        forward . /etc/resolv.conf
+    }
+
+Step 2: 1.6.5 -> 1.6.6
+  Corefile unchanged
+`,
+		},
+		{
+			name: "invalid direction",
+			flags: map[string]string{
+				"from":     "1.6.6",
+				"to":       "1.6.4",
+				"corefile": corefilePath,
+			},
+			expectedError: true,
+		},
+		{
+			name: "missing corefile",
+			flags: map[string]string{
+				"from": "1.6.4",
+				"to":   "1.6.6",
+			},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewPlanCmd(&buf)
+
+			// Silence the usage and errors output when testing expected errors.
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			for f, v := range tc.flags {
+				cmd.Flags().Set(f, v)
+			}
+			err := cmd.Execute()
+
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("%s wanted err, got nil", tc.name)
+				}
+				return
+			} else if err != nil {
+				t.Errorf("Cannot execute command: %v", err)
+			}
+
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("Expected output %v did not match %v", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
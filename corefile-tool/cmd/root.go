@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -28,21 +29,49 @@ func CorefileTool(out io.Writer) *cobra.Command {
 		`),
 	}
 	rootCmd.AddCommand(NewMigrateCmd(out))
+	rootCmd.AddCommand(NewPlanCmd(out))
 	rootCmd.AddCommand(NewDowngradeCmd(out))
+	rootCmd.AddCommand(NewFromKubeDNSCmd(out))
 	rootCmd.AddCommand(NewDefaultCmd(out))
 	rootCmd.AddCommand(NewDeprecatedCmd(out))
+	rootCmd.AddCommand(NewChangelogCmd(out))
 	rootCmd.AddCommand(NewUnsupportedCmd(out))
+	rootCmd.AddCommand(NewLintCmd(out))
+	rootCmd.AddCommand(NewFmtCmd(out))
+	rootCmd.AddCommand(NewConvertCmd(out))
+	rootCmd.AddCommand(NewCheckDeploymentCmd(out))
 	rootCmd.AddCommand(NewValidVersionsCmd(out))
 	rootCmd.AddCommand(NewReleasedCmd(out))
+	rootCmd.AddCommand(NewNormalizeCmd(out))
+	rootCmd.AddCommand(NewK8sVersionsCmd(out))
 
 	return rootCmd
 }
 
+// Exit codes of the corefile-tool.
+const (
+	exitCodeOK        = 0 // the command succeeded
+	exitCodeError     = 1 // the command failed
+	exitCodeThreshold = 2 // notices at or above the --fail-on severity were found, or fmt --check found an unformatted Corefile
+)
+
+// exitError is an error setting the exit code of the corefile-tool.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	if err := CorefileTool(os.Stdout).Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(exitCodeError)
 	}
 }
 
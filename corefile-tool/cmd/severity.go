@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// addSeverityFlags adds the --severity and --fail-on flags to a command listing notices.
+func addSeverityFlags(cmd *cobra.Command) {
+	cmd.Flags().String("severity", "all", "Only show notices of this severity or higher. [all | newdefault | deprecated | ignored | removed | unsupported]")
+	cmd.Flags().String("fail-on", "", fmt.Sprintf("Exit with code %d if any notice of this severity or higher is found. [newdefault | deprecated | ignored | removed | unsupported]", exitCodeThreshold))
+}
+
+// noticesFromFlags filters the notices by the --severity flag, and returns an exitError if any of the notices meets
+// the --fail-on flag.  The notices are returned in both cases, so they can be printed before exiting.
+func noticesFromFlags(cmd *cobra.Command, notices []migration.Notice) ([]migration.Notice, error) {
+	severity, _ := cmd.Flags().GetString("severity")
+	failOn, _ := cmd.Flags().GetString("fail-on")
+
+	shown := notices
+	if severity != "all" {
+		sev, err := migration.ParseSeverity(severity)
+		if err != nil {
+			return nil, err
+		}
+		shown = migration.NoticesAtLeast(notices, sev)
+	}
+	if failOn == "" {
+		return shown, nil
+	}
+	threshold, err := migration.ParseSeverity(failOn)
+	if err != nil {
+		return nil, err
+	}
+	if max, ok := migration.MaxSeverity(notices); ok && max.AtLeast(threshold) {
+		// the threshold being met is not a usage error
+		cmd.SilenceUsage = true
+		return shown, &exitError{code: exitCodeThreshold, err: fmt.Errorf("found notices of severity %v or higher", threshold)}
+	}
+	return shown, nil
+}
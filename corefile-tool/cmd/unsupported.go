@@ -17,17 +17,20 @@ func NewUnsupportedCmd(out io.Writer) *cobra.Command {
 		Example: `# See unsupported plugins CoreDNS from v1.4.0 to v1.5.0. 
 corefile-tool unsupported --from 1.4.0 --to 1.5.0 --corefile /path/to/Corefile`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			from, _ := cmd.Flags().GetString("from")
-			to, _ := cmd.Flags().GetString("to")
+			from, to, err := versionsFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error while listing deprecated plugins: %v \n", err)
+			}
 			corefile, _ := cmd.Flags().GetString("corefile")
 			unsupported, err := unsupportedCorefileFromPath(from, to, corefile)
 			if err != nil {
 				return fmt.Errorf("error while listing deprecated plugins: %v \n", err)
 			}
+			unsupported, thresholdErr := noticesFromFlags(cmd, unsupported)
 			for _, unsup := range unsupported {
 				fmt.Fprintln(out, unsup.ToString())
 			}
-			return nil
+			return thresholdErr
 		},
 	}
 
@@ -37,6 +40,8 @@ corefile-tool unsupported --from 1.4.0 --to 1.5.0 --corefile /path/to/Corefile`,
 	unsupportedCmd.MarkFlagRequired("to")
 	unsupportedCmd.Flags().String("corefile", "", "Required: The path where your Corefile is located.")
 	unsupportedCmd.MarkFlagRequired("corefile")
+	addSeverityFlags(unsupportedCmd)
+	addNearestPatchFlag(unsupportedCmd)
 
 	return unsupportedCmd
 }
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coredns/corefile-migration/migration"
+
+	"github.com/spf13/cobra"
+)
+
+// addNearestPatchFlag adds the --nearest-patch flag to a command taking CoreDNS versions.
+func addNearestPatchFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("nearest-patch", false, "Resolve patch releases unknown to the tool to the nearest known patch release of the same minor release.")
+}
+
+// versionResolverFromFlags returns a function resolving CoreDNS versions to the versions supported by the tool,
+// according to the --nearest-patch flag.
+func versionResolverFromFlags(cmd *cobra.Command) func(string) (string, error) {
+	nearest, _ := cmd.Flags().GetBool("nearest-patch")
+	policy := migration.PatchError
+	if nearest {
+		policy = migration.PatchNearest
+	}
+	return migration.NewMigrator(migration.DefaultCatalog(), migration.WithUnknownPatchPolicy(policy)).ResolveVersion
+}
+
+// versionsFromFlags returns the --from and --to versions, resolved according to the --nearest-patch flag.  Versions
+// not set are returned empty.
+func versionsFromFlags(cmd *cobra.Command) (string, string, error) {
+	resolve := versionResolverFromFlags(cmd)
+	versions := []string{}
+	for _, flag := range []string{"from", "to"} {
+		v, _ := cmd.Flags().GetString(flag)
+		if v != "" {
+			var err error
+			v, err = resolve(v)
+			if err != nil {
+				return "", "", fmt.Errorf("invalid --%v version: %w", flag, err)
+			}
+		}
+		versions = append(versions, v)
+	}
+	return versions[0], versions[1], nil
+}
@@ -1,6 +1,6 @@
 module github.com/coredns/corefile-migration/corefile-tool
 
-go 1.14
+go 1.18
 
 replace github.com/coredns/corefile-migration => ../
 
@@ -8,4 +8,11 @@ require (
 	github.com/coredns/corefile-migration v0.0.0
 	github.com/lithammer/dedent v1.1.0
 	github.com/spf13/cobra v1.8.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/coredns/caddy v1.1.1 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
 )
@@ -1,5 +1,8 @@
 module github.com/coredns/corefile-migration
 
-go 1.14
+go 1.18
 
-require github.com/coredns/caddy v1.1.1
+require (
+	github.com/coredns/caddy v1.1.1
+	gopkg.in/yaml.v3 v3.0.1
+)
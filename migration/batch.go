@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// BatchJob is a Corefile to migrate as part of a batch.
+type BatchJob struct {
+	Name     string // identifies the Corefile, e.g. its path
+	From     string // the CoreDNS version the Corefile is migrated from
+	To       string // the CoreDNS version the Corefile is migrated to
+	Corefile string
+}
+
+// BatchResult is the result of migrating a BatchJob.
+type BatchResult struct {
+	Job      BatchJob
+	Corefile string   // the migrated Corefile, partially migrated if Err holds MigrationErrors
+	Changed  bool     // true if the migration changes the Corefile, other than its formatting
+	Notices  []Notice // the notices raised by the migration, including unsupported plugins/options
+	Err      error
+}
+
+// MigrateBatch migrates the Corefiles of the jobs concurrently, each as MigrateWithOptions would.  See
+// Migrator.MigrateBatch.
+func MigrateBatch(jobs []BatchJob, opts MigrateOptions, workers int) []BatchResult {
+	return defaultMigrator.MigrateBatch(jobs, opts, workers)
+}
+
+// MigrateBatch migrates the Corefiles of the jobs concurrently using the Migrator's catalog, each as
+// MigrateWithOptions would, with at most workers migrations running at once (GOMAXPROCS if workers is not positive).
+// The results are returned in the order of the jobs.  A failing job does not stop the others.
+func (m *Migrator) MigrateBatch(jobs []BatchJob, opts MigrateOptions, workers int) []BatchResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	results := make([]BatchResult, len(jobs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(jobs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = m.migrateJob(jobs[i], opts)
+			}
+		}()
+	}
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func (m *Migrator) migrateJob(job BatchJob, opts MigrateOptions) BatchResult {
+	result := BatchResult{Job: job}
+	deprecated, err := m.Deprecated(job.From, job.To, job.Corefile)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	unsupported, err := m.Unsupported(job.From, job.To, job.Corefile)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Notices = append(deprecated, unsupported...)
+	result.Corefile, result.Err = m.MigrateWithOptions(job.From, job.To, job.Corefile, opts)
+	if result.Err != nil && result.Corefile == "" {
+		return result
+	}
+	if cf, err := corefile.New(job.Corefile); err == nil {
+		result.Changed = result.Corefile != cf.ToString() && result.Corefile != job.Corefile
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMigrateBatch(t *testing.T) {
+	proxyCorefile := `.:53 {
+    proxy . /etc/resolv.conf
+}
+`
+	jobs := []BatchJob{
+		{Name: "changed", From: "1.3.1", To: "1.5.0", Corefile: proxyCorefile},
+		{Name: "unchanged", From: "1.5.0", To: "1.5.2", Corefile: ".:53 {\n    forward . /etc/resolv.conf\n}\n"},
+		{Name: "reformatted", From: "1.5.0", To: "1.5.2", Corefile: ".:53 {\nforward . /etc/resolv.conf\n}"},
+		{Name: "same version", From: "1.5.0", To: "1.5.0", Corefile: proxyCorefile},
+		{Name: "unknown version", From: "0.1.0", To: "1.5.0", Corefile: proxyCorefile},
+		{Name: "invalid direction", From: "1.5.0", To: "1.3.1", Corefile: proxyCorefile},
+	}
+	for _, workers := range []int{0, 1, 2, 10} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			results := MigrateBatch(jobs, MigrateOptions{Deprecations: true}, workers)
+			if len(results) != len(jobs) {
+				t.Fatalf("Expected %v results, got %v", len(jobs), len(results))
+			}
+			for i, r := range results {
+				if r.Job.Name != jobs[i].Name {
+					t.Errorf("Expected result %v to be for job %q, got %q", i, jobs[i].Name, r.Job.Name)
+				}
+			}
+
+			if r := results[0]; r.Err != nil || !r.Changed || r.Corefile != ".:53 {\n    forward . /etc/resolv.conf\n}\n" {
+				t.Errorf("Expected the proxy plugin to be migrated, got %+v", r)
+			}
+			if r := results[0]; len(NoticesWithSeverity(r.Notices, SevDeprecated)) != 1 || len(NoticesWithSeverity(r.Notices, SevRemoved)) != 1 {
+				t.Errorf("Expected a deprecated and a removed notice, got %v", r.Notices)
+			}
+			for _, r := range results[1:4] {
+				if r.Err != nil || r.Changed {
+					t.Errorf("Job %q: expected an unchanged Corefile, got %+v", r.Job.Name, r)
+				}
+			}
+			if r := results[4]; !errors.Is(r.Err, ErrUnknownVersion) {
+				t.Errorf("Expected error '%v', got '%v'", ErrUnknownVersion, r.Err)
+			}
+			if r := results[5]; !errors.Is(r.Err, ErrInvalidDirection) {
+				t.Errorf("Expected error '%v', got '%v'", ErrInvalidDirection, r.Err)
+			}
+		})
+	}
+}
+
+func TestMigrateBatchConcurrent(t *testing.T) {
+	// many jobs sharing a new Migrator, so that its migration plans are compiled concurrently
+	m := NewMigrator(DefaultCatalog())
+	jobs := []BatchJob{}
+	for i := 0; i < 100; i++ {
+		jobs = append(jobs, BatchJob{Name: fmt.Sprint(i), From: "1.3.1", To: ValidVersions()[len(ValidVersions())-1], Corefile: largeCorefile(2)})
+	}
+	expected, err := m.Migrate(jobs[0].From, jobs[0].To, jobs[0].Corefile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = NewMigrator(DefaultCatalog())
+	for _, r := range m.MigrateBatch(jobs, MigrateOptions{}, 8) {
+		if r.Err != nil {
+			t.Fatalf("Job %v: %v", r.Job.Name, r.Err)
+		}
+		if r.Corefile != expected {
+			t.Fatalf("Job %v: expected Corefile:\n%v\nGot:\n%v", r.Job.Name, expected, r.Corefile)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangeAdded is the kind of a Change adding a plugin/option to the migration tool's catalog.  Other changes use the
+// severities SevDeprecated, SevIgnored, SevRemoved and SevNewDefault as their kind.
+const ChangeAdded = "added"
+
+// Change is a change to a plugin/option, or a rewrite rule form, in a CoreDNS release.
+type Change struct {
+	Version    string `json:"version"`
+	Plugin     string `json:"plugin"`
+	Option     string `json:"option,omitempty"`
+	Rule       string `json:"rule,omitempty"` // the form of a rewrite rule, e.g. "name regex", see RewriteRule
+	Kind       string `json:"kind"`           // 'added', 'deprecated', 'ignored', 'removed' or 'newdefault'
+	ReplacedBy string `json:"replacedBy,omitempty"`
+	Additional string `json:"additional,omitempty"`
+}
+
+func (c *Change) ToString() string {
+	s := ""
+	switch {
+	case c.Rule != "":
+		s += fmt.Sprintf(`Rule "%v" in plugin "%v" `, c.Rule, c.Plugin)
+	case c.Option != "":
+		s += fmt.Sprintf(`Option "%v" in plugin "%v" `, c.Option, c.Plugin)
+	default:
+		s += fmt.Sprintf(`Plugin "%v" `, c.Plugin)
+	}
+	switch c.Kind {
+	case ChangeAdded:
+		s += "is added in " + c.Version + "."
+	case string(SevNewDefault):
+		s += "is added as a default in " + c.Version + "."
+	default:
+		s += "is " + c.Kind + " in " + c.Version + "."
+	}
+	if c.ReplacedBy != "" {
+		s += fmt.Sprintf(` It is replaced by "%v".`, c.ReplacedBy)
+	}
+	if c.Additional != "" {
+		s += " " + c.Additional
+	}
+	return s
+}
+
+// Changes returns the plugin/option changes in the releases after fromCoreDNSVersion up to toCoreDNSVersion.
+// See Migrator.Changes.
+func Changes(fromCoreDNSVersion, toCoreDNSVersion string) ([]Change, error) {
+	return defaultMigrator.Changes(fromCoreDNSVersion, toCoreDNSVersion)
+}
+
+// Changes returns the plugin/option changes in the Migrator's catalog in the releases after fromCoreDNSVersion up to
+// toCoreDNSVersion, independent of any Corefile.  Changes are sorted by version, plugin and option.
+func (m *Migrator) Changes(fromCoreDNSVersion, toCoreDNSVersion string) ([]Change, error) {
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
+	changes := []Change{}
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return changes, nil
+	}
+	err = m.ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
+	prev := fromCoreDNSVersion
+	for {
+		v := m.catalog[prev].nextVersion
+		changes = append(changes, releaseChanges(v, m.catalog[prev].plugins, m.catalog[v].plugins)...)
+		if v == toCoreDNSVersion {
+			break
+		}
+		prev = v
+	}
+	return changes, nil
+}
+
+// releaseChanges returns the changes between the plugins of two consecutive releases.
+func releaseChanges(version string, prevPlugins, plugins map[string]plugin) []Change {
+	changes := []Change{}
+	for _, name := range sortedPluginNames(prevPlugins, plugins) {
+		prevP, pluginInPrev := prevPlugins[name]
+		p, pluginInCur := plugins[name]
+		switch {
+		case !pluginInCur:
+			// plugins are removed by moving to SevRemoved, a plugin missing from a release is not a change
+			continue
+		case !pluginInPrev && p.status != SevNewDefault:
+			changes = append(changes, Change{Version: version, Plugin: name, Kind: ChangeAdded})
+		}
+		if p.status != "" && p.status != prevP.status {
+			changes = append(changes, Change{
+				Version:    version,
+				Plugin:     name,
+				Kind:       string(p.status),
+				ReplacedBy: p.replacedBy,
+				Additional: p.additional,
+			})
+		}
+		if p.status == SevRemoved {
+			continue
+		}
+		changes = append(changes, optionChanges(version, name, pluginOptions(prevP), pluginOptions(p), pluginInPrev, false)...)
+		changes = append(changes, optionChanges(version, name, pluginRules(prevP), pluginRules(p), pluginInPrev, true)...)
+	}
+	return changes
+}
+
+// optionChanges returns the changes between the options, or the rules if rules is true, of a plugin in two
+// consecutive releases.
+func optionChanges(version, plugin string, prevOpts, opts map[string]option, pluginInPrev, rules bool) []Change {
+	changes := []Change{}
+	change := func(oName, kind string) Change {
+		c := Change{Version: version, Plugin: plugin, Option: oName, Kind: kind}
+		if rules {
+			c.Option, c.Rule = "", oName
+		}
+		return c
+	}
+	for _, oName := range sortedOptionNames(prevOpts, opts) {
+		prevO, inPrev := prevOpts[oName]
+		o, inCur := opts[oName]
+		switch {
+		case !inCur:
+			// options are removed by moving to SevRemoved, an option missing from a release is not a change
+			continue
+		case !inPrev && pluginInPrev && o.status != SevNewDefault:
+			// options of a newly added plugin are not listed separately
+			changes = append(changes, change(oName, ChangeAdded))
+		}
+		if o.status != "" && o.status != prevO.status {
+			c := change(oName, string(o.status))
+			c.ReplacedBy, c.Additional = o.replacedBy, o.additional
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// pluginRules returns the rules of a plugin, as options.
+func pluginRules(p plugin) map[string]option {
+	opts := make(map[string]option, len(p.rules))
+	for form, r := range p.rules {
+		opts[form] = option{name: form, status: r.status, replacedBy: r.replacedBy, additional: r.additional}
+	}
+	return opts
+}
+
+// pluginOptions returns the named and pattern options of a plugin.
+func pluginOptions(p plugin) map[string]option {
+	opts := make(map[string]option, len(p.namedOptions)+len(p.patternOptions))
+	for name, o := range p.namedOptions {
+		opts[name] = o
+	}
+	for pattern, o := range p.patternOptions {
+		opts[pattern] = o
+	}
+	return opts
+}
+
+func sortedPluginNames(a, b map[string]plugin) []string {
+	names := []string{}
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedOptionNames(a, b map[string]option) []string {
+	names := []string{}
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	expected := []string{
+		`Option "endpoint" in plugin "kubernetes" is ignored in 1.4.0.`,
+		`Option "upstream" in plugin "kubernetes" is deprecated in 1.4.0.`,
+		`Plugin "proxy" is deprecated in 1.4.0. It is replaced by "forward".`,
+		`Option "resyncperiod" in plugin "kubernetes" is deprecated in 1.5.0.`,
+		`Option "upstream" in plugin "kubernetes" is ignored in 1.5.0.`,
+		`Plugin "proxy" is removed in 1.5.0. It is replaced by "forward".`,
+		`Plugin "ready" is added as a default in 1.5.0.`,
+	}
+
+	result, err := Changes("1.3.1", "v1.5.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("expected to find %v changes; got %v", len(expected), len(result))
+	}
+	for i, c := range expected {
+		if result[i].ToString() != c {
+			t.Errorf("expected to get '%v'; got '%v'", c, result[i].ToString())
+		}
+	}
+
+	result, err = Changes("1.7.1", "1.8.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, c := range result {
+		found[c.ToString()] = true
+	}
+	for _, c := range []string{
+		`Option "transfer" in plugin "kubernetes" is removed in 1.8.0.`,
+		`Plugin "transfer" is added in 1.8.0.`,
+	} {
+		if !found[c] {
+			t.Errorf("expected to find '%v'", c)
+		}
+	}
+
+	result, err = Changes("1.9.4", "1.11.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = map[string]bool{}
+	for _, c := range result {
+		found[c.ToString()] = true
+	}
+	for _, c := range []string{
+		`Rule "answer auto" in plugin "rewrite" is added in 1.10.0.`,
+		`Rule "answer value" in plugin "rewrite" is added in 1.10.0.`,
+		`Rule "cname_target" in plugin "rewrite" is added in 1.11.0.`,
+		`Rule "rcode" in plugin "rewrite" is added in 1.11.3.`,
+	} {
+		if !found[c] {
+			t.Errorf("expected to find '%v'", c)
+		}
+	}
+
+	result, err = Changes("1.5.0", "1.5.0")
+	if err != nil || len(result) != 0 {
+		t.Errorf("expected no changes, got %v (%v)", result, err)
+	}
+
+	_, err = Changes("1.5.0", "1.4.0")
+	if !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got '%v'", err)
+	}
+}
+
+// TestChangesCatalog walks the whole release catalog: a plugin/option is only reported removed when the catalog
+// removes it, never because it is missing from a release.
+func TestChangesCatalog(t *testing.T) {
+	versions := ValidVersions()
+	changes, err := Changes(versions[0], versions[len(versions)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range changes {
+		if c.Kind != string(SevRemoved) {
+			continue
+		}
+		p := Versions[c.Version].plugins[c.Plugin]
+		status := p.status
+		if c.Option != "" {
+			status = pluginOptions(p)[c.Option].status
+		} else if c.Rule != "" {
+			status = p.rules[c.Rule].status
+		}
+		if status != SevRemoved {
+			t.Errorf("%v: not removed by the catalog", c.ToString())
+		}
+	}
+}
+
+// TestChangesCatalogAdded walks the whole release catalog: a plugin/option is added at most once, so no release drops
+// it from its catalog entry without removing it.
+func TestChangesCatalogAdded(t *testing.T) {
+	versions := ValidVersions()
+	changes, err := Changes(versions[0], versions[len(versions)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := map[string]string{}
+	for _, c := range changes {
+		if c.Kind != ChangeAdded {
+			continue
+		}
+		key := c.Plugin + "/" + c.Option + "/" + c.Rule
+		if v, ok := added[key]; ok {
+			t.Errorf("%v: already added in %v", c.ToString(), v)
+		}
+		added[key] = c.Version
+	}
+	if v := added["cache/serve_stale/"]; v != "1.6.6" {
+		t.Errorf("expected the serve_stale option of the cache plugin to be added in 1.6.6, got '%v'", v)
+	}
+}
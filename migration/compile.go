@@ -0,0 +1,193 @@
+package migration
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// migrationPlan is a forward migration between two releases with a set of options, compiled from the catalog: the
+// releases stepped through, with the new default plugins/options to add at each step.  Plans are immutable once
+// built, and cached by the Migrator, so that migrating many Corefiles resolves the release chain and walks the
+// catalog only once.
+type migrationPlan struct {
+	from  string
+	to    string
+	steps []*migrationStep
+}
+
+// migrationStep is a step of a migrationPlan, migrating to the release from the one directly preceding it.
+type migrationStep struct {
+	version    string
+	release    release
+	plugins    map[string]*compiledPlugin
+	newPlugins []string // the new default plugins to add, sorted by name
+}
+
+// compiledPlugin is the catalog entry of a plugin in a release, prepared for matching options.
+type compiledPlugin struct {
+	plugin
+	multiWord  map[string]bool   // the first words of the options named by several words, e.g. "answer"
+	patterns   []compiledPattern // the pattern options, sorted by pattern
+	newOptions []string          // the new default options to add, sorted by name
+	noop       bool              // true if migrating the plugin leaves it unchanged, i.e. there is no action to apply
+}
+
+type compiledPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// planKey identifies a cached migrationPlan.  Versions are resolved.  Only the options shaping a plan are part of the
+// key, so a Migrator caches at most two plans per pair of releases of its catalog.
+type planKey struct {
+	from            string
+	to              string
+	skipNewDefaults bool
+}
+
+// plan returns the plan migrating from fromCoreDNSVersion up to toCoreDNSVersion with opts, compiling and caching it
+// on first use.  Versions must be resolved.
+func (m *Migrator) plan(fromCoreDNSVersion, toCoreDNSVersion string, opts MigrateOptions) (*migrationPlan, error) {
+	key := planKey{from: fromCoreDNSVersion, to: toCoreDNSVersion, skipNewDefaults: opts.SkipNewDefaults}
+	if p, ok := m.plans.Load(key); ok {
+		return p.(*migrationPlan), nil
+	}
+	p, err := m.compilePlan(fromCoreDNSVersion, toCoreDNSVersion, MigrateOptions{SkipNewDefaults: opts.SkipNewDefaults})
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := m.plans.LoadOrStore(key, p)
+	return cached.(*migrationPlan), nil
+}
+
+// compiledPlugins returns the catalog entries of the plugins of a release, prepared for matching options, compiling
+// and caching them on first use.  The version must be in the catalog.  A Migrator caches at most one entry per
+// release of its catalog.
+func (m *Migrator) compiledPlugins(v string) map[string]*compiledPlugin {
+	if cps, ok := m.releases.Load(v); ok {
+		return cps.(map[string]*compiledPlugin)
+	}
+	cps := compileStep(v, m.catalog[v], MigrateOptions{SkipNewDefaults: true}).plugins
+	cached, _ := m.releases.LoadOrStore(v, cps)
+	return cached.(map[string]*compiledPlugin)
+}
+
+func (m *Migrator) compilePlan(fromCoreDNSVersion, toCoreDNSVersion string, opts MigrateOptions) (*migrationPlan, error) {
+	if err := m.ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion); err != nil {
+		return nil, err
+	}
+	p := &migrationPlan{from: fromCoreDNSVersion, to: toCoreDNSVersion}
+	for v := fromCoreDNSVersion; v != toCoreDNSVersion; {
+		v = m.catalog[v].nextVersion
+		p.steps = append(p.steps, compileStep(v, m.catalog[v], opts))
+	}
+	return p, nil
+}
+
+func compileStep(v string, r release, opts MigrateOptions) *migrationStep {
+	step := &migrationStep{version: v, release: r, plugins: make(map[string]*compiledPlugin, len(r.plugins))}
+	for name, vp := range r.plugins {
+		step.plugins[name] = compilePlugin(vp, opts)
+		if !opts.SkipNewDefaults && vp.status == SevNewDefault {
+			step.newPlugins = append(step.newPlugins, name)
+		}
+	}
+	sort.Strings(step.newPlugins)
+	return step
+}
+
+func compilePlugin(vp plugin, opts MigrateOptions) *compiledPlugin {
+	cp := &compiledPlugin{plugin: vp}
+	for pattern := range vp.patternOptions {
+		if re := compilePattern(pattern); re != nil {
+			cp.patterns = append(cp.patterns, compiledPattern{pattern: pattern, re: re})
+		}
+	}
+	sort.Slice(cp.patterns, func(i, j int) bool { return cp.patterns[i].pattern < cp.patterns[j].pattern })
+	for name, vo := range vp.namedOptions {
+		if i := strings.Index(name, " "); i > 0 {
+			if cp.multiWord == nil {
+				cp.multiWord = map[string]bool{}
+			}
+			cp.multiWord[name[:i]] = true
+		}
+		if !opts.SkipNewDefaults && vo.status == SevNewDefault {
+			cp.newOptions = append(cp.newOptions, name)
+		}
+	}
+	sort.Strings(cp.newOptions)
+	cp.noop = vp.action == nil && len(cp.newOptions) == 0 && !hasOptionAction(vp.namedOptions) &&
+		!hasOptionAction(vp.patternOptions) && !hasRuleAction(vp.rules)
+	return cp
+}
+
+func hasOptionAction(options map[string]option) bool {
+	for _, o := range options {
+		if o.action != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRuleAction(rules map[string]rule) bool {
+	for _, r := range rules {
+		if r.action != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOption returns the option of the plugin's catalog entry matching the option.  Options named by several words,
+// e.g. "answer name", match an option by its name followed by its first argument, and are preferred over options
+// named by the option's name only.  Valid inline entries of the hosts plugin match an option without status.  A nil
+// compiledPlugin, i.e. a plugin not in the catalog, matches no option.
+func (cp *compiledPlugin) matchOption(opt *corefile.Option) (*option, bool) {
+	if cp == nil {
+		return nil, false
+	}
+	if len(opt.Args) > 0 && cp.multiWord[opt.Name] {
+		name := opt.Name + " " + opt.Args[0]
+		if o, exists := cp.namedOptions[name]; exists {
+			o.name = name
+			return &o, true
+		}
+	}
+	if o, exists := cp.namedOptions[opt.Name]; exists {
+		o.name = opt.Name
+		return &o, true
+	}
+	for _, p := range cp.patterns {
+		if p.re.MatchString(opt.Name) {
+			o := cp.patternOptions[p.pattern]
+			o.name = opt.Name
+			return &o, true
+		}
+	}
+	if cp.inlineEntries {
+		if _, err := ParseHostsEntry(opt); err == nil {
+			return &option{name: opt.Name}, true
+		}
+	}
+	return nil, false
+}
+
+// patterns caches the compiled pattern options of all catalogs, keyed by pattern.  Patterns failing to compile are
+// cached as nil, and never match.  Since catalogs can only hold releases shipped with this library (see Catalog), the
+// cache holds at most the pattern options of Versions.
+var patterns sync.Map
+
+// compilePattern returns the compiled pattern option, or nil if it does not compile, compiling it on first use.
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := regexp.Compile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
@@ -0,0 +1,176 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+func TestMigrationPlan(t *testing.T) {
+	m := NewMigrator(DefaultCatalog())
+	p, err := m.plan("1.3.1", "1.5.0", MigrateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.from != "1.3.1" || p.to != "1.5.0" || len(p.steps) != 2 || p.steps[0].version != "1.4.0" || p.steps[1].version != "1.5.0" {
+		t.Errorf("Expected a plan stepping through 1.4.0 and 1.5.0, got %+v", p)
+	}
+	if names := p.steps[1].newPlugins; len(names) != 1 || names[0] != "ready" {
+		t.Errorf("Expected the ready plugin to be added in 1.5.0, got %v", names)
+	}
+
+	cached, err := m.plan("1.3.1", "1.5.0", MigrateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != p {
+		t.Error("Expected the plan to be cached")
+	}
+	other, err := m.plan("1.3.1", "1.5.0", MigrateOptions{SkipNewDefaults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == p || len(other.steps[1].newPlugins) != 0 {
+		t.Error("Expected a plan without new default plugins for other options")
+	}
+
+	same, err := m.plan("1.3.1", "1.5.0", MigrateOptions{Deprecations: true, ContinueOnError: true, AbortSeverity: SevRemoved})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same != p {
+		t.Error("Expected options not shaping the plan to share the cached plan")
+	}
+
+	if _, err := m.plan("1.5.0", "1.3.1", MigrateOptions{}); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("Expected error '%v', got '%v'", ErrInvalidDirection, err)
+	}
+
+	cps := m.compiledPlugins("1.5.0")
+	if cps["kubernetes"] == nil || cps["proxy"] == nil {
+		t.Errorf("Expected the plugins of 1.5.0 to be compiled, got %v", cps)
+	}
+	if cached := m.compiledPlugins("1.5.0"); cached["kubernetes"] != cps["kubernetes"] {
+		t.Error("Expected the compiled plugins of a release to be cached")
+	}
+	if o, matched := cps["no_such_plugin"].matchOption(&corefile.Option{Name: "ttl"}); matched || o != nil {
+		t.Error("Expected a plugin not in the catalog to match no option")
+	}
+}
+
+func TestCompilePlugin(t *testing.T) {
+	cp := compilePlugin(plugin{
+		namedOptions: map[string]option{
+			"answer name": {},
+			"answer":      {status: SevNewDefault},
+			"ttl":         {},
+		},
+		patternOptions: map[string]option{
+			`^\d+$`: {status: SevDeprecated},
+			`[`:     {}, // does not compile, never matches
+		},
+	}, MigrateOptions{})
+
+	tests := []struct {
+		option   *corefile.Option
+		expected string
+		status   Severity
+	}{
+		{option: &corefile.Option{Name: "answer", Args: []string{"name", "a", "b"}}, expected: "answer name"},
+		{option: &corefile.Option{Name: "answer", Args: []string{"value", "a", "b"}}, expected: "answer", status: SevNewDefault},
+		{option: &corefile.Option{Name: "ttl", Args: []string{"30"}}, expected: "ttl"},
+		{option: &corefile.Option{Name: "42"}, expected: "42", status: SevDeprecated},
+		{option: &corefile.Option{Name: "["}},
+	}
+	for _, test := range tests {
+		o, matched := cp.matchOption(test.option)
+		if matched != (test.expected != "") {
+			t.Errorf("Option %q: expected matched to be %v", test.option.ToString(), !matched)
+			continue
+		}
+		if matched && (o.name != test.expected || o.status != test.status) {
+			t.Errorf("Option %q: expected %q (%v), got %q (%v)", test.option.ToString(), test.expected, test.status, o.name, o.status)
+		}
+	}
+	if len(cp.newOptions) != 1 || cp.newOptions[0] != "answer" || cp.noop {
+		t.Errorf("Expected the answer option to be added, got %v", cp.newOptions)
+	}
+	if !compilePlugin(plugin{namedOptions: map[string]option{"ttl": {}}}, MigrateOptions{}).noop {
+		t.Error("Expected a plugin without actions to be a noop")
+	}
+}
+
+// largeCorefile returns a Corefile with n server blocks, each with the plugins of the default Kubernetes deployment,
+// options unknown to the catalog, and inline hosts entries.
+func largeCorefile(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `zone%d.example.org:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+        custom_option %d
+    }
+    hosts {
+        10.0.%d.1 a.zone%d.example.org
+        fd00::%x b.zone%d.example.org
+        fallthrough
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+`, i, i, i%256, i, i+1, i)
+	}
+	return sb.String()
+}
+
+// BenchmarkMigrate migrates Corefiles of increasing size with a Migrator reused across migrations, as the package
+// level functions do, and with a new Migrator for each migration, compiling the migration plan every time.
+func BenchmarkMigrate(b *testing.B) {
+	for _, n := range []int{1, 10, 100} {
+		startCorefile := largeCorefile(n)
+		b.Run(fmt.Sprintf("blocks=%d/cached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Migrate("1.3.1", "1.11.1", startCorefile, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("blocks=%d/uncached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewMigrator(Versions).Migrate("1.3.1", "1.11.1", startCorefile, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMatchPattern matches an option against a pattern option, compiled once and compiled on every match.
+func BenchmarkMatchPattern(b *testing.B) {
+	pattern := `\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}`
+	b.Run("compiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			compilePattern(pattern).MatchString("custom_option")
+		}
+	})
+	b.Run("uncompiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = regexp.MatchString(pattern, "custom_option")
+		}
+	})
+}
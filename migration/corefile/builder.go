@@ -0,0 +1,172 @@
+package corefile
+
+import (
+	"fmt"
+)
+
+// Validator checks a plugin, or one of its options, as it is added to a ServerBuilder.  The option is nil when the
+// plugin itself is checked.  Validators allow rejecting plugins/options not valid for a CoreDNS version, see
+// migration.Validator.
+type Validator func(p *Plugin, o *Option) error
+
+// ServerBuilder builds a server block one plugin/option at a time, e.g.
+//
+//	NewServer(".:53").Plugin("forward", ".", "/etc/resolv.conf").Option("max_concurrent", "1000")
+//
+// Each addition is validated as it is made.  The first error is kept and returned by Build, and any further
+// additions are ignored.
+type ServerBuilder struct {
+	server     *Server
+	plugin     *Plugin // the plugin options are added to
+	validators []Validator
+	err        error
+}
+
+// NewServer returns a ServerBuilder for a server block with the keys, e.g. ".:53".
+func NewServer(domPorts ...string) *ServerBuilder {
+	b := &ServerBuilder{server: &Server{DomPorts: domPorts}}
+	if err := b.server.validate(); err != nil {
+		b.err = fmt.Errorf("%w: server block %q: %v", ErrInvalid, domPorts, err)
+	}
+	return b
+}
+
+// WithValidator adds validators to the builder.  Plugins/options already added are checked right away.
+func (b *ServerBuilder) WithValidator(validators ...Validator) *ServerBuilder {
+	if b.err != nil {
+		return b
+	}
+	for _, p := range b.server.Plugins {
+		if b.err = validatePlugin(validators, p, nil); b.err != nil {
+			return b
+		}
+		for _, o := range p.Options {
+			if b.err = validatePlugin(validators, p, o); b.err != nil {
+				return b
+			}
+		}
+	}
+	b.validators = append(b.validators, validators...)
+	return b
+}
+
+// Plugin adds a plugin to the server block.  Options added next are added to this plugin.
+func (b *ServerBuilder) Plugin(name string, args ...string) *ServerBuilder {
+	if b.err != nil {
+		return b
+	}
+	p := &Plugin{Name: name, Args: args}
+	if err := p.validate(); err != nil {
+		b.err = fmt.Errorf("%w: plugin: %v", ErrInvalid, err)
+		return b
+	}
+	if b.err = validatePlugin(b.validators, p, nil); b.err != nil {
+		return b
+	}
+	b.server.AddPlugin(p)
+	b.plugin = p
+	return b
+}
+
+// Option adds an option to the last plugin added.
+func (b *ServerBuilder) Option(name string, args ...string) *ServerBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.plugin == nil {
+		b.err = fmt.Errorf("%w: option %q added before any plugin", ErrInvalid, name)
+		return b
+	}
+	o := &Option{Name: name, Args: args}
+	if err := o.validate(); err != nil {
+		b.err = fmt.Errorf("%w: plugin %q: option: %v", ErrInvalid, b.plugin.Name, err)
+		return b
+	}
+	if b.err = validatePlugin(b.validators, b.plugin, o); b.err != nil {
+		return b
+	}
+	b.plugin.AddOption(o)
+	return b
+}
+
+// Build returns the server block, or the first error found while building it.
+func (b *ServerBuilder) Build() (*Server, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.server.Clone(), nil
+}
+
+// ToString returns the server block rendered by Server.ToString, or the first error found while building it.
+func (b *ServerBuilder) ToString() (string, error) {
+	s, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+	return s.ToString(), nil
+}
+
+// CorefileBuilder builds a Corefile from server blocks built by ServerBuilders.
+type CorefileBuilder struct {
+	servers    []*ServerBuilder
+	validators []Validator
+}
+
+// NewCorefile returns a CorefileBuilder for a Corefile with the server blocks.
+func NewCorefile(servers ...*ServerBuilder) *CorefileBuilder {
+	return &CorefileBuilder{servers: servers}
+}
+
+// WithValidator adds validators to the builder, checking the plugins/options of all of its server blocks.
+func (b *CorefileBuilder) WithValidator(validators ...Validator) *CorefileBuilder {
+	b.validators = append(b.validators, validators...)
+	return b
+}
+
+// Server adds a server block to the Corefile.
+func (b *CorefileBuilder) Server(s *ServerBuilder) *CorefileBuilder {
+	b.servers = append(b.servers, s)
+	return b
+}
+
+// Build returns the Corefile, or the first error found while building its server blocks.
+func (b *CorefileBuilder) Build() (*Corefile, error) {
+	cf := &Corefile{}
+	for _, sb := range b.servers {
+		s, err := sb.Build()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range s.Plugins {
+			if err := validatePlugin(b.validators, p, nil); err != nil {
+				return nil, err
+			}
+			for _, o := range p.Options {
+				if err := validatePlugin(b.validators, p, o); err != nil {
+					return nil, err
+				}
+			}
+		}
+		cf.AddServer(s)
+	}
+	return cf, nil
+}
+
+// ToString returns the Corefile rendered by Corefile.ToString, or the first error found while building it.
+func (b *CorefileBuilder) ToString() (string, error) {
+	cf, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+	return cf.ToString(), nil
+}
+
+// validatePlugin runs the validators on the plugin, or on its option if o is not nil.
+func validatePlugin(validators []Validator, p *Plugin, o *Option) error {
+	for _, v := range validators {
+		if err := v(p, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package corefile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestServerBuilder(t *testing.T) {
+	str, err := NewServer(".:53").
+		Plugin("errors").
+		Plugin("forward", ".", "/etc/resolv.conf").Option("max_concurrent", "1000").
+		Plugin("template", "ANY", "A", "example.org").Option("answer", "{{ .Name }} 60 IN A 127.0.0.1").
+		Plugin("cache", "30").
+		ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.:53 {
+    errors
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+    template ANY A example.org {
+        answer "{{ .Name }} 60 IN A 127.0.0.1"
+    }
+    cache 30
+}
+`
+	if str != expected {
+		t.Errorf("Expected Corefile:\n%v\nGot:\n%v", expected, str)
+	}
+	cf, err := New(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.ToString() != str {
+		t.Errorf("Expected the built server block to parse back, got:\n%v", cf.ToString())
+	}
+}
+
+func TestServerBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ServerBuilder
+	}{
+		{name: "no keys", builder: NewServer().Plugin("errors")},
+		{name: "empty key", builder: NewServer("").Plugin("errors")},
+		{name: "empty plugin name", builder: NewServer(".:53").Plugin("")},
+		{name: "lone brace key", builder: NewServer(".:53", "{").Plugin("errors")},
+		{name: "lone brace argument", builder: NewServer(".:53").Plugin("forward", "{")},
+		{name: "option before plugin", builder: NewServer(".:53").Option("max_concurrent", "1000")},
+		{name: "option name with brace", builder: NewServer(".:53").Plugin("forward", ".", "8.8.8.8").Option("{")},
+		{name: "error kept", builder: NewServer(".:53").Plugin("").Plugin("errors")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.builder.Build(); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Expected error '%v', got '%v'", ErrInvalid, err)
+			}
+		})
+	}
+}
+
+func TestBuilderValidator(t *testing.T) {
+	errNoProxy := errors.New("no proxy")
+	noProxy := func(p *Plugin, o *Option) error {
+		if p.Name == "proxy" {
+			return errNoProxy
+		}
+		if o != nil && o.Name == "spray" {
+			return errNoProxy
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		builder func() (*Corefile, error)
+		err     error
+	}{
+		{
+			name: "valid",
+			builder: func() (*Corefile, error) {
+				return NewCorefile(NewServer(".:53").WithValidator(noProxy).Plugin("forward", ".", "8.8.8.8")).Build()
+			},
+		},
+		{
+			name: "invalid plugin",
+			builder: func() (*Corefile, error) {
+				return NewCorefile(NewServer(".:53").WithValidator(noProxy).Plugin("proxy", ".", "8.8.8.8")).Build()
+			},
+			err: errNoProxy,
+		},
+		{
+			name: "invalid option",
+			builder: func() (*Corefile, error) {
+				return NewCorefile(NewServer(".:53").WithValidator(noProxy).Plugin("forward", ".", "8.8.8.8").Option("spray")).Build()
+			},
+			err: errNoProxy,
+		},
+		{
+			name: "validator added after the plugin",
+			builder: func() (*Corefile, error) {
+				return NewCorefile(NewServer(".:53").Plugin("proxy", ".", "8.8.8.8").WithValidator(noProxy)).Build()
+			},
+			err: errNoProxy,
+		},
+		{
+			name: "corefile validator",
+			builder: func() (*Corefile, error) {
+				return NewCorefile(NewServer(".:53").Plugin("forward", ".", "8.8.8.8")).
+					Server(NewServer("example.org").Plugin("proxy", ".", "8.8.8.8")).
+					WithValidator(noProxy).
+					Build()
+			},
+			err: errNoProxy,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cf, err := test.builder()
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error '%v', got '%v'", test.err, err)
+			}
+			if err == nil && len(cf.Servers) != 1 {
+				t.Errorf("Expected 1 server block, got %v", len(cf.Servers))
+			}
+		})
+	}
+}
@@ -1,34 +1,50 @@
 package corefile
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/coredns/caddy/caddyfile"
 )
 
 type Corefile struct {
-	Servers []*Server
+	Servers     []*Server `json:"servers" yaml:"servers"`
+	EndComments []string  `json:"endComments,omitempty" yaml:"endComments,omitempty"` // comments after the last server block
 }
 
 type Server struct {
-	DomPorts []string
-	Plugins  []*Plugin
+	DomPorts    []string  `json:"domPorts" yaml:"domPorts"`
+	Plugins     []*Plugin `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+	Comments    []string  `json:"comments,omitempty" yaml:"comments,omitempty"`       // comment lines preceding the server block
+	Comment     string    `json:"comment,omitempty" yaml:"comment,omitempty"`         // comment at the end of the server block's first line
+	EndComments []string  `json:"endComments,omitempty" yaml:"endComments,omitempty"` // comment lines before the server block's closing brace
+	EndComment  string    `json:"endComment,omitempty" yaml:"endComment,omitempty"`   // comment at the end of the server block's closing brace line
 }
 
 type Plugin struct {
-	Name    string
-	Args    []string
-	Options []*Option
+	Name        string    `json:"name" yaml:"name"`
+	Args        []string  `json:"args,omitempty" yaml:"args,omitempty"`
+	Options     []*Option `json:"options,omitempty" yaml:"options,omitempty"`
+	Comments    []string  `json:"comments,omitempty" yaml:"comments,omitempty"`       // comment lines preceding the plugin
+	Comment     string    `json:"comment,omitempty" yaml:"comment,omitempty"`         // comment at the end of the plugin's first line
+	EndComments []string  `json:"endComments,omitempty" yaml:"endComments,omitempty"` // comment lines before the plugin's closing brace
+	EndComment  string    `json:"endComment,omitempty" yaml:"endComment,omitempty"`   // comment at the end of the plugin's closing brace line
 }
 
 type Option struct {
-	Name string
-	Args []string
+	Name     string   `json:"name" yaml:"name"`
+	Args     []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Comments []string `json:"comments,omitempty" yaml:"comments,omitempty"` // comment lines preceding the option
+	Comment  string   `json:"comment,omitempty" yaml:"comment,omitempty"`   // comment at the end of the option's line
 }
 
 func New(s string) (*Corefile, error) {
 	c := Corefile{}
 	cc := caddyfile.NewDispenser("migration", strings.NewReader(s))
+	cm := newComments(s)
 	depth := 0
 	var cSvr *Server
 	var cPlg *Plugin
@@ -37,9 +53,18 @@ func New(s string) (*Corefile, error) {
 			depth += 1
 			continue
 		} else if cc.Val() == "}" {
+			switch depth {
+			case 1:
+				cSvr.EndComments = append(cSvr.EndComments, cm.before(cc.Line())...)
+				cSvr.EndComment = cm.at(cc.Line())
+			case 2:
+				cPlg.EndComments = append(cPlg.EndComments, cm.before(cc.Line())...)
+				cPlg.EndComment = cm.at(cc.Line())
+			}
 			depth -= 1
 			continue
 		}
+		line := cc.Line()
 		val := cc.Val()
 		args := cc.RemainingArgs()
 		switch depth {
@@ -47,79 +72,292 @@ func New(s string) (*Corefile, error) {
 			c.Servers = append(c.Servers,
 				&Server{
 					DomPorts: append([]string{val}, args...),
+					Comments: cm.before(line),
+					Comment:  cm.at(line),
 				})
 			cSvr = c.Servers[len(c.Servers)-1]
 		case 1:
 			cSvr.Plugins = append(cSvr.Plugins,
 				&Plugin{
-					Name: val,
-					Args: args,
+					Name:     val,
+					Args:     args,
+					Comments: cm.before(line),
+					Comment:  cm.at(line),
 				})
 			cPlg = cSvr.Plugins[len(cSvr.Plugins)-1]
 		case 2:
 			cPlg.Options = append(cPlg.Options,
 				&Option{
-					Name: val,
-					Args: args,
+					Name:     val,
+					Args:     args,
+					Comments: cm.before(line),
+					Comment:  cm.at(line),
 				})
 		}
 	}
+	c.EndComments = cm.rest()
 	return &c, nil
 }
 
+// comments holds the comments of a Corefile by line number, and hands them out to the Corefile nodes in order.
+type comments struct {
+	text     map[int]string
+	trailing map[int]bool // true if the comment follows a token on the same line
+	lines    []int        // the lines of the comments not handed out yet, in order
+}
+
+// newComments collects the comments in s, following the same quoting and line counting rules as the Caddyfile lexer.
+func newComments(s string) *comments {
+	cm := &comments{text: map[int]string{}, trailing: map[int]bool{}}
+	line := 1
+	var comment, quoted, escaped, inToken, tokenOnLine bool
+	var text []rune
+	endComment := func() {
+		cm.text[line] = strings.TrimRightFunc(string(text), unicode.IsSpace)
+		cm.trailing[line] = tokenOnLine
+		cm.lines = append(cm.lines, line)
+		comment, text = false, nil
+	}
+	for _, ch := range s {
+		if comment {
+			if ch == '\n' {
+				endComment()
+				line++
+				tokenOnLine = false
+				continue
+			}
+			text = append(text, ch)
+			continue
+		}
+		if quoted {
+			if ch == '\n' {
+				line++
+			}
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				quoted, inToken = false, false
+			}
+			continue
+		}
+		if unicode.IsSpace(ch) {
+			if ch == '\n' {
+				line++
+				tokenOnLine = false
+			}
+			inToken = false
+			continue
+		}
+		if ch == '#' {
+			comment, inToken = true, false
+			text = []rune{ch}
+			continue
+		}
+		if !inToken && ch == '"' {
+			quoted = true
+		}
+		inToken, tokenOnLine = true, true
+	}
+	if comment {
+		endComment()
+	}
+	sort.Ints(cm.lines)
+	return cm
+}
+
+// before hands out the comments on the lines before the given line.
+func (cm *comments) before(line int) []string {
+	var strs []string
+	for len(cm.lines) > 0 && cm.lines[0] < line {
+		strs = append(strs, cm.text[cm.lines[0]])
+		cm.lines = cm.lines[1:]
+	}
+	return strs
+}
+
+// at hands out the comment following a token on the given line, if any.
+func (cm *comments) at(line int) string {
+	if len(cm.lines) == 0 || cm.lines[0] != line || !cm.trailing[line] {
+		return ""
+	}
+	cm.lines = cm.lines[1:]
+	return cm.text[line]
+}
+
+// rest hands out all remaining comments.
+func (cm *comments) rest() []string {
+	var strs []string
+	for _, l := range cm.lines {
+		strs = append(strs, cm.text[l])
+	}
+	cm.lines = nil
+	return strs
+}
+
 func (c *Corefile) ToString() (out string) {
 	strs := []string{}
 	for _, s := range c.Servers {
 		strs = append(strs, s.ToString())
 	}
+	if len(c.EndComments) > 0 {
+		strs = append(strs, strings.Join(c.EndComments, "\n")+"\n")
+	}
 	return strings.Join(strs, "\n")
 }
 
 func (s *Server) ToString() (out string) {
-	str := strings.Join(escapeArgs(s.DomPorts), " ")
+	str := ""
+	for _, c := range s.Comments {
+		str += c + "\n"
+	}
+	str += strings.Join(escapeArgs(s.DomPorts), " ")
 	strs := []string{}
 	for _, p := range s.Plugins {
+		strs = append(strs, commentLines(p.Comments, indent)...)
 		strs = append(strs, strings.Repeat(" ", indent)+p.ToString())
 	}
-	if len(strs) > 0 {
-		str += " {\n" + strings.Join(strs, "\n") + "\n}\n"
+	strs = append(strs, commentLines(s.EndComments, indent)...)
+	if len(strs) > 0 || s.EndComment != "" {
+		str += " {" + trailingComment(s.Comment) + "\n" + strings.Join(append(strs, "}"+trailingComment(s.EndComment)), "\n") + "\n"
+	} else {
+		str += trailingComment(s.Comment)
 	}
 	return str
 }
 
 func (p *Plugin) ToString() (out string) {
-	str := strings.Join(append([]string{p.Name}, escapeArgs(p.Args)...), " ")
+	str := strings.Join(escapeArgs(append([]string{p.Name}, p.Args...)), " ")
 	strs := []string{}
 	for _, o := range p.Options {
+		strs = append(strs, commentLines(o.Comments, indent*2)...)
 		strs = append(strs, strings.Repeat(" ", indent*2)+o.ToString())
 	}
-	if len(strs) > 0 {
-		str += " {\n" + strings.Join(strs, "\n") + "\n" + strings.Repeat(" ", indent*1) + "}"
+	strs = append(strs, commentLines(p.EndComments, indent*2)...)
+	if len(strs) > 0 || p.EndComment != "" {
+		str += " {" + trailingComment(p.Comment) + "\n" + strings.Join(append(strs, strings.Repeat(" ", indent*1)+"}"+trailingComment(p.EndComment)), "\n")
+	} else {
+		str += trailingComment(p.Comment)
 	}
 	return str
 }
 
 func (o *Option) ToString() (out string) {
-	str := strings.Join(append([]string{o.Name}, escapeArgs(o.Args)...), " ")
-	return str
+	str := strings.Join(escapeArgs(append([]string{o.Name}, o.Args...)), " ")
+	return str + trailingComment(o.Comment)
 }
 
-// escapeArgs returns the arguments list escaping and wrapping any argument containing whitespace in quotes
+// commentLines returns the comments as indented lines.
+func commentLines(comments []string, indentation int) []string {
+	lines := []string{}
+	for _, c := range comments {
+		lines = append(lines, strings.Repeat(" ", indentation)+c)
+	}
+	return lines
+}
+
+func trailingComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return " " + comment
+}
+
+// escapeArgs returns the tokens as written in a Corefile, see escapeToken.
 func escapeArgs(args []string) []string {
 	var escapedArgs []string
 	for _, a := range args {
-		// if there is white space, wrap argument with quotes
-		if len(strings.Fields(a)) > 1 {
-			// escape quotes
-			a = strings.Replace(a, "\"", "\\\"", -1)
-			// wrap with quotes
-			a = "\"" + a + "\""
-		}
-		escapedArgs = append(escapedArgs, a)
+		escapedArgs = append(escapedArgs, escapeToken(a))
 	}
 	return escapedArgs
 }
 
+// escapeToken returns the token as written in a Corefile, wrapped in quotes if the Caddyfile lexer would otherwise
+// read it as a different token, or none at all.  Quotes are escaped inside quotes, which is the only escape the lexer
+// reverses.  Tokens that cannot be written so that they are read back as they are (see checkToken) are written as
+// well as possible.
+func escapeToken(t string) string {
+	if !needsQuotes(t) {
+		return t
+	}
+	return "\"" + strings.Replace(t, "\"", "\\\"", -1) + "\""
+}
+
+// needsQuotes returns true if the token is read back as it is only when wrapped in quotes: it is empty, it contains
+// whitespace or the start of a comment, or it starts with a quote or a byte order mark.
+func needsQuotes(t string) bool {
+	if t == "" || strings.HasPrefix(t, "\"") || strings.HasPrefix(t, "\uFEFF") {
+		return true
+	}
+	return strings.IndexFunc(t, func(r rune) bool { return unicode.IsSpace(r) || r == '#' }) >= 0
+}
+
+// checkToken returns an error if the token cannot be written in a Corefile so that it is read back as it is: a lone
+// brace is always read as a block delimiter, the lexer replaces invalid UTF-8, and inside quotes a backslash cannot
+// precede a quote or the closing quote.
+func checkToken(t string) error {
+	switch {
+	case t == "{" || t == "}":
+		return fmt.Errorf("token %q is a block delimiter", t)
+	case !utf8.ValidString(t):
+		return fmt.Errorf("token %q is not valid UTF-8", t)
+	case needsQuotes(t) && (strings.Contains(t, "\\\"") || strings.HasSuffix(t, "\\")):
+		return fmt.Errorf("token %q cannot be quoted", t)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the Corefile.
+func (c *Corefile) Clone() *Corefile {
+	clone := &Corefile{EndComments: append([]string(nil), c.EndComments...)}
+	for _, s := range c.Servers {
+		clone.Servers = append(clone.Servers, s.Clone())
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the server block.
+func (s *Server) Clone() *Server {
+	clone := &Server{
+		DomPorts:    append([]string(nil), s.DomPorts...),
+		Comments:    append([]string(nil), s.Comments...),
+		Comment:     s.Comment,
+		EndComments: append([]string(nil), s.EndComments...),
+		EndComment:  s.EndComment,
+	}
+	for _, p := range s.Plugins {
+		clone.Plugins = append(clone.Plugins, p.Clone())
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the plugin.
+func (p *Plugin) Clone() *Plugin {
+	clone := &Plugin{
+		Name:        p.Name,
+		Args:        append([]string(nil), p.Args...),
+		Comments:    append([]string(nil), p.Comments...),
+		Comment:     p.Comment,
+		EndComments: append([]string(nil), p.EndComments...),
+		EndComment:  p.EndComment,
+	}
+	for _, o := range p.Options {
+		clone.Options = append(clone.Options, o.Clone())
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the option.
+func (o *Option) Clone() *Option {
+	return &Option{
+		Name:     o.Name,
+		Args:     append([]string(nil), o.Args...),
+		Comments: append([]string(nil), o.Comments...),
+		Comment:  o.Comment,
+	}
+}
+
 func (s *Server) FindMatch(def []*Server) (*Server, bool) {
 NextServer:
 	for _, sDef := range def {
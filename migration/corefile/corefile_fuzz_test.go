@@ -0,0 +1,17 @@
+package corefile
+
+import (
+	"testing"
+)
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(".:53", "forward", ".", "/etc/resolv.conf")
+	f.Add("example.org", "template", "{{ .Name }} 60 IN A 127.0.0.1", "")
+	f.Add("\"quoted\"", "a#b", "say \"hi\"", "back\\slash")
+	f.Add("\uFEFF.", "{{", "new\nline", "\t")
+	f.Fuzz(func(t *testing.T, key, name, arg1, arg2 string) {
+		if err := roundTrip(key, name, []string{arg1, arg2}); err != nil {
+			t.Error(err)
+		}
+	})
+}
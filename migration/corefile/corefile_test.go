@@ -1,6 +1,8 @@
 package corefile
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -130,3 +132,225 @@ func TestOption_FindMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestCorefileComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		corefile string
+		expected string
+	}{
+		{
+			name: "canonical",
+			corefile: `# main server block
+.:53 { # all zones
+    # report errors
+    errors
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        # serve pod records
+        pods insecure # deprecated in later releases
+        fallthrough in-addr.arpa ip6.arpa
+        # ttl 30
+    }
+    forward . "/etc/resolv.conf" # "quoted"
+    # cache 30
+}
+
+# stub domain
+example.org:53 {
+    forward . 10.0.0.1
+}
+# trailing comment
+`,
+			expected: `# main server block
+.:53 { # all zones
+    # report errors
+    errors
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        # serve pod records
+        pods insecure # deprecated in later releases
+        fallthrough in-addr.arpa ip6.arpa
+        # ttl 30
+    }
+    forward . /etc/resolv.conf # "quoted"
+    # cache 30
+}
+
+# stub domain
+example.org:53 {
+    forward . 10.0.0.1
+}
+
+# trailing comment
+`,
+		},
+		{
+			name: "closing brace comments",
+			corefile: `example.org {
+    forward . 1.2.3.4
+} # end of example.org
+.:53 { # default
+    cache {
+        success 100
+    } # end of cache
+    reload
+}
+`,
+			expected: `example.org {
+    forward . 1.2.3.4
+} # end of example.org
+
+.:53 { # default
+    cache {
+        success 100
+    } # end of cache
+    reload
+}
+`,
+		},
+		{
+			name: "messy",
+			corefile: `  #header
+.:53 {
+errors   # log errors
+
+
+   health {
+   lameduck 5s   
+   } # after health
+  template ANY A example.org {
+      answer "{{ .Name }} 60 IN A 127.0.0.1 # not a comment"
+  }
+}`,
+			expected: `#header
+.:53 {
+    errors # log errors
+    health {
+        lameduck 5s
+    } # after health
+    template ANY A example.org {
+        answer "{{ .Name }} 60 IN A 127.0.0.1 # not a comment"
+    }
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := New(test.corefile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := c.ToString()
+			if got != test.expected {
+				t.Fatalf("Corefile did not match expected.\nExpected:\n%v\nGot:\n%v", test.expected, got)
+			}
+			// formatting must be stable
+			c, err = New(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again := c.ToString(); again != got {
+				t.Errorf("Formatting is not stable.\nFirst:\n%v\nSecond:\n%v", got, again)
+			}
+			// the clone keeps the comments
+			if clone := c.Clone().ToString(); clone != got {
+				t.Errorf("Clone did not match.\nExpected:\n%v\nGot:\n%v", got, clone)
+			}
+		})
+	}
+}
+
+func TestCorefileEndComment(t *testing.T) {
+	// the comment after a closing brace moves with its server block
+	c, err := New(`a.example.org {
+    forward . 1.2.3.4
+} # end of a
+b.example.org {
+    forward . 5.6.7.8
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Servers[0], c.Servers[1] = c.Servers[1], c.Servers[0]
+	expected := `b.example.org {
+    forward . 5.6.7.8
+}
+
+a.example.org {
+    forward . 1.2.3.4
+} # end of a
+`
+	if c.ToString() != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, c.ToString())
+	}
+}
+
+func TestEscapeToken(t *testing.T) {
+	tests := []struct {
+		token    string
+		expected string
+	}{
+		{token: "cluster.local", expected: `cluster.local`},
+		{token: "", expected: `""`},
+		{token: "my arg", expected: `"my arg"`},
+		{token: "tab\targ", expected: "\"tab\targ\""},
+		{token: "new\nline", expected: "\"new\nline\""},
+		{token: "a#b", expected: `"a#b"`},
+		{token: "#comment", expected: `"#comment"`},
+		{token: `"quoted"`, expected: `"\"quoted\""`},
+		{token: `mid"quote`, expected: `mid"quote`},
+		{token: `say "hi"`, expected: `"say \"hi\""`},
+		{token: `back\slash`, expected: `back\slash`},
+		{token: `back\ slash`, expected: `"back\ slash"`},
+		{token: `{$ENV}`, expected: `{$ENV}`},
+		{token: `{{`, expected: `{{`},
+		{token: "\uFEFFbom", expected: "\"\uFEFFbom\""},
+	}
+	for _, test := range tests {
+		if escaped := escapeToken(test.token); escaped != test.expected {
+			t.Errorf("Expected %q to be written as %v, got %v", test.token, test.expected, escaped)
+		}
+		if err := roundTrip(test.token, test.token, []string{test.token}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	for _, token := range []string{"{", "}", "\xff", `a \" b`, `a b\`, `"\"`} {
+		if err := checkToken(token); err == nil {
+			t.Errorf("Expected token %q to be rejected", token)
+		}
+	}
+	for _, token := range []string{"", "{{", `a\"b`, `a\`, `a \ b`} {
+		if err := checkToken(token); err != nil {
+			t.Errorf("Expected token %q to be accepted, got %v", token, err)
+		}
+	}
+}
+
+// roundTrip writes a Corefile with the tokens as server block keys, plugin/option names and arguments, and returns an
+// error if it is not read back as the same tokens.  Tokens rejected by Validate are not checked.
+func roundTrip(key, name string, args []string) error {
+	cf := &Corefile{Servers: []*Server{{
+		DomPorts: append([]string{key}, args...),
+		Plugins: []*Plugin{
+			{Name: name, Args: args, Options: []*Option{{Name: name, Args: args}, {Name: "next"}}},
+			{Name: "errors"},
+		},
+	}}}
+	if cf.Validate() != nil {
+		return nil
+	}
+	str := cf.ToString()
+	parsed, err := New(str)
+	if err != nil {
+		return fmt.Errorf("cannot parse %q: %v", str, err)
+	}
+	if !reflect.DeepEqual(parsed, cf) {
+		return fmt.Errorf("%q is not read back as the same tokens: %q", str, parsed.ToString())
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package corefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the JSON and YAML encoding of a Corefile, described by JSONSchema.  It is bumped
+// on any incompatible change of the encoding.
+const SchemaVersion = "v1"
+
+var (
+	// ErrSchemaVersion is returned when decoding a Corefile encoded with an unknown schema version.
+	ErrSchemaVersion = errors.New("unsupported Corefile schema version")
+	// ErrInvalid is returned when a Corefile cannot be rendered as it is, e.g. a plugin without a name.
+	ErrInvalid = errors.New("invalid Corefile")
+)
+
+// document is the encoding of a Corefile, versioned by its schema version.
+type document struct {
+	SchemaVersion string    `json:"schemaVersion" yaml:"schemaVersion"`
+	Servers       []*Server `json:"servers" yaml:"servers"`
+	EndComments   []string  `json:"endComments,omitempty" yaml:"endComments,omitempty"`
+}
+
+func (c Corefile) document() document {
+	servers := c.Servers
+	if servers == nil {
+		servers = []*Server{}
+	}
+	return document{SchemaVersion: SchemaVersion, Servers: servers, EndComments: c.EndComments}
+}
+
+func (c *Corefile) fromDocument(doc document) error {
+	if doc.SchemaVersion != SchemaVersion {
+		return fmt.Errorf("%w '%v'", ErrSchemaVersion, doc.SchemaVersion)
+	}
+	cf := Corefile{Servers: doc.Servers, EndComments: doc.EndComments}
+	if err := cf.Validate(); err != nil {
+		return err
+	}
+	*c = cf
+	return nil
+}
+
+// MarshalJSON encodes the Corefile as JSON, following JSONSchema.
+func (c Corefile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.document())
+}
+
+// UnmarshalJSON decodes a Corefile encoded as JSON, following JSONSchema.  The Corefile is validated.
+func (c *Corefile) UnmarshalJSON(data []byte) error {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return c.fromDocument(doc)
+}
+
+// MarshalYAML encodes the Corefile as YAML, with the same layout as the JSON encoding.
+func (c Corefile) MarshalYAML() (interface{}, error) {
+	return c.document(), nil
+}
+
+// UnmarshalYAML decodes a Corefile encoded as YAML, with the same layout as the JSON encoding.  The Corefile is
+// validated.
+func (c *Corefile) UnmarshalYAML(value *yaml.Node) error {
+	var doc document
+	if err := value.Decode(&doc); err != nil {
+		return err
+	}
+	return c.fromDocument(doc)
+}
+
+// Validate returns an error if the Corefile cannot be rendered by ToString and parsed back by New as it is: server
+// blocks must have keys, keys and plugin/option names must not be empty, every token must be read back as it is
+// written (e.g. an argument cannot be a lone brace), and comments must be single lines starting with "#".
+func (c *Corefile) Validate() error {
+	if err := validComments(c.EndComments...); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for i, s := range c.Servers {
+		if s == nil {
+			return fmt.Errorf("%w: server block %d is empty", ErrInvalid, i)
+		}
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("%w: server block %d: %v", ErrInvalid, i, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) validate() error {
+	if len(s.DomPorts) == 0 {
+		return errors.New("no zones/ports")
+	}
+	for _, dp := range s.DomPorts {
+		if err := validToken(dp); err != nil {
+			return err
+		}
+	}
+	if err := validComments(append(append([]string{s.Comment, s.EndComment}, s.Comments...), s.EndComments...)...); err != nil {
+		return err
+	}
+	for i, p := range s.Plugins {
+		if p == nil {
+			return fmt.Errorf("plugin %d is empty", i)
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("plugin %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) validate() error {
+	if err := validToken(p.Name); err != nil {
+		return err
+	}
+	if err := validArgs(p.Args); err != nil {
+		return err
+	}
+	if err := validComments(append(append([]string{p.Comment, p.EndComment}, p.Comments...), p.EndComments...)...); err != nil {
+		return err
+	}
+	for i, o := range p.Options {
+		if o == nil {
+			return fmt.Errorf("option %d is empty", i)
+		}
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("option %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (o *Option) validate() error {
+	if err := validToken(o.Name); err != nil {
+		return err
+	}
+	if err := validArgs(o.Args); err != nil {
+		return err
+	}
+	return validComments(append([]string{o.Comment}, o.Comments...)...)
+}
+
+// validToken returns an error if the name is empty, or is not read back as it is written.
+func validToken(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	return checkToken(name)
+}
+
+// validArgs returns an error if any of the arguments is not read back as it is written.
+func validArgs(args []string) error {
+	for _, a := range args {
+		if err := checkToken(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validComments returns an error if any of the non empty comments does not start with "#" or spans several lines.
+func validComments(comments ...string) error {
+	for _, c := range comments {
+		if c == "" {
+			continue
+		}
+		if !strings.HasPrefix(c, "#") || strings.ContainsAny(c, "\r\n") {
+			return fmt.Errorf("invalid comment %q", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package corefile
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const encodingCorefile = `# the default server block
+.:53 { # all zones
+    errors
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        # pod records
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa # reverse zones
+    }
+    forward . /etc/resolv.conf
+    cache 30
+}
+
+"exam ple.com:53" {
+    template ANY A foo.bar.com {
+        answer "{{ .Name }} 59 IN CNAME foo2.bar.com"
+    }
+}
+
+# end of file
+`
+
+func TestCorefileJSON(t *testing.T) {
+	cf, err := New(encodingCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Corefile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ToString() != encodingCorefile {
+		t.Errorf("Expected Corefile to round trip through JSON, got:\n%v", decoded.ToString())
+	}
+
+	redata, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(redata) != string(data) {
+		t.Errorf("Expected JSON to round trip through Corefile.\nExpected: %v\nGot:      %v", string(data), string(redata))
+	}
+}
+
+func TestCorefileYAML(t *testing.T) {
+	cf, err := New(encodingCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Corefile
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ToString() != encodingCorefile {
+		t.Errorf("Expected Corefile to round trip through YAML, got:\n%v", decoded.ToString())
+	}
+
+	redata, err := yaml.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(redata) != string(data) {
+		t.Errorf("Expected YAML to round trip through Corefile.\nExpected: %v\nGot:      %v", string(data), string(redata))
+	}
+}
+
+func TestCorefileJSONEncoding(t *testing.T) {
+	cf, err := New(".:53 {\n    forward . /etc/resolv.conf {\n        max_concurrent 1000\n    }\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"schemaVersion":"v1","servers":[{"domPorts":[".:53"],"plugins":[{"name":"forward","args":[".","/etc/resolv.conf"],"options":[{"name":"max_concurrent","args":["1000"]}]}]}]}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON:\n%v\nGot:\n%v", expected, string(data))
+	}
+
+	data, err = json.Marshal(&Corefile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"schemaVersion":"v1","servers":[]}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON:\n%v\nGot:\n%v", expected, string(data))
+	}
+}
+
+func TestCorefileDecodeErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		json        string
+		expectedErr error
+	}{
+		{
+			name:        "missing schema version",
+			json:        `{"servers":[]}`,
+			expectedErr: ErrSchemaVersion,
+		},
+		{
+			name:        "unknown schema version",
+			json:        `{"schemaVersion":"v2","servers":[]}`,
+			expectedErr: ErrSchemaVersion,
+		},
+		{
+			name:        "server block without keys",
+			json:        `{"schemaVersion":"v1","servers":[{"plugins":[{"name":"errors"}]}]}`,
+			expectedErr: ErrInvalid,
+		},
+		{
+			name:        "plugin without a name",
+			json:        `{"schemaVersion":"v1","servers":[{"domPorts":[".:53"],"plugins":[{"args":["30"]}]}]}`,
+			expectedErr: ErrInvalid,
+		},
+		{
+			name:        "option name is a brace",
+			json:        `{"schemaVersion":"v1","servers":[{"domPorts":[".:53"],"plugins":[{"name":"forward","options":[{"name":"}"}]}]}]}`,
+			expectedErr: ErrInvalid,
+		},
+		{
+			name:        "unquotable argument",
+			json:        `{"schemaVersion":"v1","servers":[{"domPorts":[".:53"],"plugins":[{"name":"forward","args":["a \\\" b"]}]}]}`,
+			expectedErr: ErrInvalid,
+		},
+		{
+			name:        "comment without #",
+			json:        `{"schemaVersion":"v1","servers":[{"domPorts":[".:53"],"comment":"all zones"}]}`,
+			expectedErr: ErrInvalid,
+		},
+		{
+			name:        "multi-line comment",
+			json:        `{"schemaVersion":"v1","servers":[],"endComments":["# one\n# two"]}`,
+			expectedErr: ErrInvalid,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cf Corefile
+			err := json.Unmarshal([]byte(tc.json), &cf)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error '%v', got '%v'", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	published, err := ioutil.ReadFile("corefile.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published) != JSONSchema {
+		t.Error("Expected corefile.schema.json to match JSONSchema")
+	}
+
+	var schema struct {
+		Properties struct {
+			SchemaVersion struct {
+				Const string `json:"const"`
+			} `json:"schemaVersion"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(JSONSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Properties.SchemaVersion.Const != SchemaVersion {
+		t.Errorf("Expected JSONSchema for schema version %v, got %v", SchemaVersion, schema.Properties.SchemaVersion.Const)
+	}
+}
@@ -0,0 +1,297 @@
+package corefile
+
+import (
+	"strings"
+)
+
+// ArgsPredicate reports whether the arguments of a plugin match, to narrow down the plugins found by name.
+type ArgsPredicate func(args []string) bool
+
+// ArgsEqual matches arguments equal to args.
+func ArgsEqual(args ...string) ArgsPredicate {
+	return func(a []string) bool {
+		if len(a) != len(args) {
+			return false
+		}
+		for i := range args {
+			if a[i] != args[i] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ArgsHavePrefix matches arguments starting with args, e.g. ArgsHavePrefix(".") matches the forward plugin for the
+// root zone.
+func ArgsHavePrefix(args ...string) ArgsPredicate {
+	return func(a []string) bool {
+		return len(a) >= len(args) && ArgsEqual(args...)(a[:len(args)])
+	}
+}
+
+// ArgsContain matches arguments containing arg.
+func ArgsContain(arg string) ArgsPredicate {
+	return func(a []string) bool {
+		for _, x := range a {
+			if x == arg {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FindServers returns the server blocks serving the zone on the port, in order.  Zones are compared case
+// insensitively, fully qualified, and keys without a port use the default port of their transport (e.g. 53 for dns).
+// An empty zone or port matches any zone or port.
+func (c *Corefile) FindServers(zone, port string) []*Server {
+	servers := []*Server{}
+	for _, s := range c.Servers {
+		if s.Serves(zone, port) {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+// FindPlugins returns the plugins of all server blocks with the name and arguments matching all of the predicates, in
+// order.
+func (c *Corefile) FindPlugins(name string, preds ...ArgsPredicate) []*Plugin {
+	plugins := []*Plugin{}
+	for _, s := range c.Servers {
+		plugins = append(plugins, s.FindPlugins(name, preds...)...)
+	}
+	return plugins
+}
+
+// AddServer appends the server block to the Corefile.
+func (c *Corefile) AddServer(s *Server) {
+	c.Servers = append(c.Servers, s)
+}
+
+// RemoveServer removes the server block from the Corefile, and returns false if it is not in the Corefile.
+func (c *Corefile) RemoveServer(s *Server) bool {
+	i := c.serverIndex(s)
+	if i < 0 {
+		return false
+	}
+	c.Servers = append(c.Servers[:i], c.Servers[i+1:]...)
+	return true
+}
+
+// MoveServer moves the server block to the index in the Corefile, shifting the server blocks in between.  It returns
+// false if the server block is not in the Corefile or the index is out of range.
+func (c *Corefile) MoveServer(s *Server, index int) bool {
+	i := c.serverIndex(s)
+	if i < 0 || index < 0 || index >= len(c.Servers) {
+		return false
+	}
+	if i < index {
+		copy(c.Servers[i:index], c.Servers[i+1:index+1])
+	} else {
+		copy(c.Servers[index+1:i+1], c.Servers[index:i])
+	}
+	c.Servers[index] = s
+	return true
+}
+
+func (c *Corefile) serverIndex(s *Server) int {
+	for i, sb := range c.Servers {
+		if sb == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Name returns the keys of the server block separated by spaces, e.g. ".:53", as used to report its location.
+func (s *Server) Name() string {
+	return strings.Join(s.DomPorts, " ")
+}
+
+// Serves returns true if any of the keys of the server block is for the zone on the port, with the same rules as
+// Corefile.FindServers.
+func (s *Server) Serves(zone, port string) bool {
+	for _, dp := range s.DomPorts {
+		sa := ParseServerAddr(dp)
+		if (zone == "" || sa.Zone == NormalizeZone(zone)) && (port == "" || sa.Port == port) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindPlugins returns the plugins of the server block with the name and arguments matching all of the predicates, in
+// order.
+func (s *Server) FindPlugins(name string, preds ...ArgsPredicate) []*Plugin {
+	plugins := []*Plugin{}
+	for _, p := range s.Plugins {
+		if p.matches(name, preds) {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// FindPlugin returns the first plugin of the server block with the name and arguments matching all of the
+// predicates, or nil if there is none.
+func (s *Server) FindPlugin(name string, preds ...ArgsPredicate) *Plugin {
+	for _, p := range s.Plugins {
+		if p.matches(name, preds) {
+			return p
+		}
+	}
+	return nil
+}
+
+// AddPlugin appends the plugin to the server block.
+func (s *Server) AddPlugin(p *Plugin) {
+	s.Plugins = append(s.Plugins, p)
+}
+
+// InsertPluginBefore inserts the plugin before the first plugin with the name, and returns false, leaving the server
+// block unchanged, if there is none.
+func (s *Server) InsertPluginBefore(name string, p *Plugin) bool {
+	for i, sp := range s.Plugins {
+		if sp.Name == name {
+			s.insertPlugin(i, p)
+			return true
+		}
+	}
+	return false
+}
+
+// InsertPluginAfter inserts the plugin after the last plugin with the name, and returns false, leaving the server
+// block unchanged, if there is none.
+func (s *Server) InsertPluginAfter(name string, p *Plugin) bool {
+	for i := len(s.Plugins) - 1; i >= 0; i-- {
+		if s.Plugins[i].Name == name {
+			s.insertPlugin(i+1, p)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) insertPlugin(i int, p *Plugin) {
+	s.Plugins = append(s.Plugins, nil)
+	copy(s.Plugins[i+1:], s.Plugins[i:])
+	s.Plugins[i] = p
+}
+
+// RemovePlugins removes the plugins with the name and arguments matching all of the predicates from the server
+// block, and returns the number of plugins removed.
+func (s *Server) RemovePlugins(name string, preds ...ArgsPredicate) int {
+	plugins := []*Plugin{}
+	for _, p := range s.Plugins {
+		if !p.matches(name, preds) {
+			plugins = append(plugins, p)
+		}
+	}
+	removed := len(s.Plugins) - len(plugins)
+	s.Plugins = plugins
+	return removed
+}
+
+func (p *Plugin) matches(name string, preds []ArgsPredicate) bool {
+	if p.Name != name {
+		return false
+	}
+	for _, pred := range preds {
+		if !pred(p.Args) {
+			return false
+		}
+	}
+	return true
+}
+
+// Option returns the first option of the plugin with the name, or nil if there is none.
+func (p *Plugin) Option(name string) *Option {
+	for _, o := range p.Options {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// AddOption appends the option to the plugin.
+func (p *Plugin) AddOption(o *Option) {
+	p.Options = append(p.Options, o)
+}
+
+// SetOption sets the arguments of the first option of the plugin with the name, appending the option if there is
+// none, and returns the option.
+func (p *Plugin) SetOption(name string, args ...string) *Option {
+	o := p.Option(name)
+	if o == nil {
+		o = &Option{Name: name}
+		p.AddOption(o)
+	}
+	o.Args = args
+	return o
+}
+
+// RemoveOption removes the options of the plugin with the name, and returns false if there are none.
+func (p *Plugin) RemoveOption(name string) bool {
+	options := []*Option{}
+	for _, o := range p.Options {
+		if o.Name != name {
+			options = append(options, o)
+		}
+	}
+	removed := len(options) != len(p.Options)
+	p.Options = options
+	return removed
+}
+
+// ServerAddr is a parsed server block key, e.g. "dns://example.org:53".  ServerAddrs of keys for the same zone and
+// port are equal.
+type ServerAddr struct {
+	Scheme string // the transport in lower case, "dns" if the key has none
+	Zone   string // the zone in lower case and fully qualified
+	Port   string // the port, the default port of the transport if the key has none
+}
+
+// defaultPorts holds the default port of each transport.
+var defaultPorts = map[string]string{"dns": "53", "tls": "853", "quic": "853", "grpc": "443", "https": "443"}
+
+// DefaultPort returns the default port of the transport, e.g. "53" for "dns", or "" for an unknown transport.
+func DefaultPort(scheme string) string {
+	return defaultPorts[strings.ToLower(scheme)]
+}
+
+// SplitServerKey splits a server block key into its transport, without "://", its zone and its port, as written.
+// Each part is empty if the key has none.
+func SplitServerKey(key string) (scheme, zone, port string) {
+	if i := strings.Index(key, "://"); i >= 0 {
+		scheme, key = key[:i], key[i+3:]
+	}
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		key, port = key[:i], key[i+1:]
+	}
+	return scheme, key, port
+}
+
+// ParseServerAddr parses a server block key, filling in the default transport and port.
+func ParseServerAddr(key string) ServerAddr {
+	scheme, zone, port := SplitServerKey(key)
+	sa := ServerAddr{Scheme: strings.ToLower(scheme), Zone: NormalizeZone(zone), Port: port}
+	if sa.Scheme == "" {
+		sa.Scheme = "dns"
+	}
+	if sa.Port == "" {
+		sa.Port = DefaultPort(sa.Scheme)
+	}
+	return sa
+}
+
+// NormalizeZone returns the zone in lower case and fully qualified.
+func NormalizeZone(zone string) string {
+	zone = strings.ToLower(zone)
+	if !strings.HasSuffix(zone, ".") {
+		zone += "."
+	}
+	return zone
+}
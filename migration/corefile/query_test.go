@@ -0,0 +1,329 @@
+package corefile
+
+import (
+	"strings"
+	"testing"
+)
+
+const queryCorefile = `.:53 {
+    errors
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    forward . /etc/resolv.conf
+    forward example.org 10.0.0.1
+    cache 30
+}
+
+Example.ORG {
+    forward . 10.0.0.1
+}
+
+tls://example.org:5353 {
+    forward . 10.0.0.2
+}
+`
+
+func TestCorefile_FindServers(t *testing.T) {
+	cf, err := New(queryCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		zone     string
+		port     string
+		expected []*Server
+	}{
+		{zone: ".", port: "53", expected: []*Server{cf.Servers[0]}},
+		{zone: "example.org.", port: "53", expected: []*Server{cf.Servers[1]}},
+		{zone: "example.org", port: "", expected: []*Server{cf.Servers[1], cf.Servers[2]}},
+		{zone: "", port: "5353", expected: []*Server{cf.Servers[2]}},
+		{zone: "", port: "", expected: cf.Servers},
+		{zone: "example.com", port: "", expected: []*Server{}},
+	}
+	for _, test := range tests {
+		servers := cf.FindServers(test.zone, test.port)
+		if len(servers) != len(test.expected) {
+			t.Errorf("Zone %q, port %q: expected %v server blocks, got %v", test.zone, test.port, len(test.expected), len(servers))
+			continue
+		}
+		for i := range servers {
+			if servers[i] != test.expected[i] {
+				t.Errorf("Zone %q, port %q: expected server block %q, got %q", test.zone, test.port, test.expected[i].DomPorts, servers[i].DomPorts)
+			}
+		}
+	}
+}
+
+func TestParseServerAddr(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected ServerAddr
+	}{
+		{key: ".", expected: ServerAddr{Scheme: "dns", Zone: ".", Port: "53"}},
+		{key: ".:53", expected: ServerAddr{Scheme: "dns", Zone: ".", Port: "53"}},
+		{key: "Example.ORG", expected: ServerAddr{Scheme: "dns", Zone: "example.org.", Port: "53"}},
+		{key: "dns://example.org.:1053", expected: ServerAddr{Scheme: "dns", Zone: "example.org.", Port: "1053"}},
+		{key: "TLS://example.org", expected: ServerAddr{Scheme: "tls", Zone: "example.org.", Port: "853"}},
+		{key: "https://.", expected: ServerAddr{Scheme: "https", Zone: ".", Port: "443"}},
+		{key: "grpc://10.0.0.0/8:8053", expected: ServerAddr{Scheme: "grpc", Zone: "10.0.0.0/8.", Port: "8053"}},
+	}
+	for _, test := range tests {
+		if got := ParseServerAddr(test.key); got != test.expected {
+			t.Errorf("Key %q: expected %+v, got %+v", test.key, test.expected, got)
+		}
+	}
+	if scheme, zone, port := SplitServerKey("TLS://Example.org:853"); scheme != "TLS" || zone != "Example.org" || port != "853" {
+		t.Errorf("Expected the parts of the key as written, got %q, %q, %q", scheme, zone, port)
+	}
+}
+
+func TestServer_Name(t *testing.T) {
+	s := &Server{DomPorts: []string{"example.org:53", "tls://example.org"}}
+	if got := s.Name(); got != "example.org:53 tls://example.org" {
+		t.Errorf("Expected the keys of the server block, got %q", got)
+	}
+}
+
+func TestServer_FindPlugins(t *testing.T) {
+	cf, err := New(queryCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := cf.Servers[0]
+	tests := []struct {
+		name     string
+		preds    []ArgsPredicate
+		expected []string
+	}{
+		{name: "forward", expected: []string{"forward . /etc/resolv.conf", "forward example.org 10.0.0.1"}},
+		{name: "forward", preds: []ArgsPredicate{ArgsHavePrefix(".")}, expected: []string{"forward . /etc/resolv.conf"}},
+		{name: "forward", preds: []ArgsPredicate{ArgsEqual("example.org", "10.0.0.1")}, expected: []string{"forward example.org 10.0.0.1"}},
+		{name: "forward", preds: []ArgsPredicate{ArgsEqual("example.org")}, expected: []string{}},
+		{name: "forward", preds: []ArgsPredicate{ArgsContain("10.0.0.1"), ArgsHavePrefix(".")}, expected: []string{}},
+		{name: "kubernetes", preds: []ArgsPredicate{ArgsContain("ip6.arpa")}, expected: []string{s.Plugins[1].ToString()}},
+		{name: "proxy", expected: []string{}},
+	}
+	for i, test := range tests {
+		plugins := s.FindPlugins(test.name, test.preds...)
+		if len(plugins) != len(test.expected) {
+			t.Errorf("In test #%v, expected %v plugins, got %v", i, len(test.expected), len(plugins))
+			continue
+		}
+		for j, p := range plugins {
+			if p.ToString() != test.expected[j] {
+				t.Errorf("In test #%v, expected plugin %q, got %q", i, test.expected[j], p.ToString())
+			}
+		}
+		first := s.FindPlugin(test.name, test.preds...)
+		if (first == nil) != (len(plugins) == 0) || (first != nil && first != plugins[0]) {
+			t.Errorf("In test #%v, expected FindPlugin to return the first plugin found", i)
+		}
+	}
+
+	if plugins := cf.FindPlugins("forward", ArgsHavePrefix(".")); len(plugins) != 3 {
+		t.Errorf("Expected 3 forward plugins for the root zone in the Corefile, got %v", len(plugins))
+	}
+}
+
+func TestServer_InsertPlugin(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   func(s *Server) bool
+		ok       bool
+		expected []string
+	}{
+		{
+			name:     "add",
+			insert:   func(s *Server) bool { s.AddPlugin(&Plugin{Name: "loop"}); return true },
+			ok:       true,
+			expected: []string{"errors", "forward", "cache", "forward", "loop"},
+		},
+		{
+			name:     "before first",
+			insert:   func(s *Server) bool { return s.InsertPluginBefore("forward", &Plugin{Name: "loop"}) },
+			ok:       true,
+			expected: []string{"errors", "loop", "forward", "cache", "forward"},
+		},
+		{
+			name:     "before the first plugin",
+			insert:   func(s *Server) bool { return s.InsertPluginBefore("errors", &Plugin{Name: "loop"}) },
+			ok:       true,
+			expected: []string{"loop", "errors", "forward", "cache", "forward"},
+		},
+		{
+			name:     "after last",
+			insert:   func(s *Server) bool { return s.InsertPluginAfter("forward", &Plugin{Name: "loop"}) },
+			ok:       true,
+			expected: []string{"errors", "forward", "cache", "forward", "loop"},
+		},
+		{
+			name:     "after",
+			insert:   func(s *Server) bool { return s.InsertPluginAfter("errors", &Plugin{Name: "loop"}) },
+			ok:       true,
+			expected: []string{"errors", "loop", "forward", "cache", "forward"},
+		},
+		{
+			name:     "missing plugin",
+			insert:   func(s *Server) bool { return s.InsertPluginAfter("health", &Plugin{Name: "loop"}) },
+			ok:       false,
+			expected: []string{"errors", "forward", "cache", "forward"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{DomPorts: []string{".:53"}, Plugins: []*Plugin{{Name: "errors"}, {Name: "forward"}, {Name: "cache"}, {Name: "forward"}}}
+			if ok := test.insert(s); ok != test.ok {
+				t.Errorf("Expected %v, got %v", test.ok, ok)
+			}
+			if names := pluginNames(s); names != joinNames(test.expected) {
+				t.Errorf("Expected plugins %v, got %v", joinNames(test.expected), names)
+			}
+		})
+	}
+}
+
+func TestServer_RemovePlugins(t *testing.T) {
+	cf, err := New(queryCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := cf.Servers[0]
+	if n := s.RemovePlugins("forward", ArgsHavePrefix("example.org")); n != 1 {
+		t.Errorf("Expected 1 plugin removed, got %v", n)
+	}
+	if names := pluginNames(s); names != "errors kubernetes forward cache" {
+		t.Errorf("Expected plugins errors kubernetes forward cache, got %v", names)
+	}
+	if n := s.RemovePlugins("proxy"); n != 0 {
+		t.Errorf("Expected no plugin removed, got %v", n)
+	}
+	if n := s.RemovePlugins("forward"); n != 1 {
+		t.Errorf("Expected 1 plugin removed, got %v", n)
+	}
+	if names := pluginNames(s); names != "errors kubernetes cache" {
+		t.Errorf("Expected plugins errors kubernetes cache, got %v", names)
+	}
+}
+
+func TestPlugin_Options(t *testing.T) {
+	cf, err := New(queryCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := cf.Servers[0].FindPlugin("kubernetes")
+
+	if o := p.Option("pods"); o == nil || o.ToString() != "pods insecure" {
+		t.Errorf("Expected option pods insecure, got %v", o)
+	}
+	if o := p.Option("ttl"); o != nil {
+		t.Errorf("Expected no ttl option, got %v", o.ToString())
+	}
+
+	p.SetOption("pods", "verified")
+	p.SetOption("ttl", "30")
+	p.AddOption(&Option{Name: "ttl", Args: []string{"60"}})
+	if !p.RemoveOption("fallthrough") {
+		t.Error("Expected the fallthrough option to be removed")
+	}
+	if p.RemoveOption("fallthrough") {
+		t.Error("Expected no fallthrough option to remove")
+	}
+	expected := `kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods verified
+        ttl 30
+        ttl 60
+    }`
+	if p.ToString() != expected {
+		t.Errorf("Expected plugin:\n%v\nGot:\n%v", expected, p.ToString())
+	}
+
+	if !p.RemoveOption("ttl") || p.Option("ttl") != nil {
+		t.Error("Expected all ttl options to be removed")
+	}
+}
+
+func TestCorefile_Servers(t *testing.T) {
+	newCorefile := func() *Corefile {
+		return &Corefile{Servers: []*Server{
+			{DomPorts: []string{"a"}}, {DomPorts: []string{"b"}}, {DomPorts: []string{"c"}}, {DomPorts: []string{"d"}},
+		}}
+	}
+	tests := []struct {
+		name     string
+		change   func(c *Corefile) bool
+		ok       bool
+		expected string
+	}{
+		{
+			name:     "add",
+			change:   func(c *Corefile) bool { c.AddServer(&Server{DomPorts: []string{"e"}}); return true },
+			ok:       true,
+			expected: "a b c d e",
+		},
+		{
+			name:     "remove",
+			change:   func(c *Corefile) bool { return c.RemoveServer(c.Servers[1]) },
+			ok:       true,
+			expected: "a c d",
+		},
+		{
+			name:     "remove missing",
+			change:   func(c *Corefile) bool { return c.RemoveServer(&Server{DomPorts: []string{"b"}}) },
+			ok:       false,
+			expected: "a b c d",
+		},
+		{
+			name:     "move forward",
+			change:   func(c *Corefile) bool { return c.MoveServer(c.Servers[0], 2) },
+			ok:       true,
+			expected: "b c a d",
+		},
+		{
+			name:     "move backward",
+			change:   func(c *Corefile) bool { return c.MoveServer(c.Servers[3], 0) },
+			ok:       true,
+			expected: "d a b c",
+		},
+		{
+			name:     "move in place",
+			change:   func(c *Corefile) bool { return c.MoveServer(c.Servers[1], 1) },
+			ok:       true,
+			expected: "a b c d",
+		},
+		{
+			name:     "move out of range",
+			change:   func(c *Corefile) bool { return c.MoveServer(c.Servers[1], 4) },
+			ok:       false,
+			expected: "a b c d",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCorefile()
+			if ok := test.change(c); ok != test.ok {
+				t.Errorf("Expected %v, got %v", test.ok, ok)
+			}
+			names := []string{}
+			for _, s := range c.Servers {
+				names = append(names, s.DomPorts[0])
+			}
+			if joinNames(names) != test.expected {
+				t.Errorf("Expected server blocks %v, got %v", test.expected, joinNames(names))
+			}
+		})
+	}
+}
+
+func pluginNames(s *Server) string {
+	names := []string{}
+	for _, p := range s.Plugins {
+		names = append(names, p.Name)
+	}
+	return joinNames(names)
+}
+
+func joinNames(names []string) string {
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,135 @@
+package corefile
+
+// JSONSchema is the JSON Schema of the JSON encoding of a Corefile, for the schema version SchemaVersion.  It is
+// also published as corefile.schema.json, next to this file.
+const JSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Corefile",
+  "description": "A CoreDNS Corefile, as encoded by the corefile package of corefile-migration.",
+  "type": "object",
+  "required": ["schemaVersion", "servers"],
+  "additionalProperties": false,
+  "properties": {
+    "schemaVersion": {
+      "description": "The version of this schema.",
+      "const": "v1"
+    },
+    "servers": {
+      "description": "The server blocks, in order.",
+      "type": "array",
+      "items": { "$ref": "#/$defs/server" }
+    },
+    "endComments": {
+      "description": "The comment lines after the last server block.",
+      "$ref": "#/$defs/comments"
+    }
+  },
+  "$defs": {
+    "token": {
+      "description": "A token, quoted when written in the Corefile if needed.  Lone braces are block delimiters, and a token needing quotes cannot contain a backslash followed by a quote, nor end with a backslash.",
+      "type": "string",
+      "not": { "enum": ["{", "}"] }
+    },
+    "name": {
+      "$ref": "#/$defs/token",
+      "minLength": 1
+    },
+    "comment": {
+      "type": "string",
+      "pattern": "^#[^\\r\\n]*$"
+    },
+    "comments": {
+      "type": "array",
+      "items": { "$ref": "#/$defs/comment" }
+    },
+    "args": {
+      "type": "array",
+      "items": { "$ref": "#/$defs/token" }
+    },
+    "server": {
+      "description": "A server block.",
+      "type": "object",
+      "required": ["domPorts"],
+      "additionalProperties": false,
+      "properties": {
+        "domPorts": {
+          "description": "The keys of the server block, e.g. \".:53\".",
+          "type": "array",
+          "minItems": 1,
+          "items": { "$ref": "#/$defs/name" }
+        },
+        "plugins": {
+          "description": "The plugins of the server block, in order.",
+          "type": "array",
+          "items": { "$ref": "#/$defs/plugin" }
+        },
+        "comments": {
+          "description": "The comment lines preceding the server block.",
+          "$ref": "#/$defs/comments"
+        },
+        "comment": {
+          "description": "The comment at the end of the server block's first line.",
+          "$ref": "#/$defs/comment"
+        },
+        "endComments": {
+          "description": "The comment lines before the server block's closing brace.",
+          "$ref": "#/$defs/comments"
+        },
+        "endComment": {
+          "description": "The comment at the end of the server block's closing brace line.",
+          "$ref": "#/$defs/comment"
+        }
+      }
+    },
+    "plugin": {
+      "description": "A plugin of a server block.",
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": { "$ref": "#/$defs/name" },
+        "args": { "$ref": "#/$defs/args" },
+        "options": {
+          "description": "The options of the plugin, in order.",
+          "type": "array",
+          "items": { "$ref": "#/$defs/option" }
+        },
+        "comments": {
+          "description": "The comment lines preceding the plugin.",
+          "$ref": "#/$defs/comments"
+        },
+        "comment": {
+          "description": "The comment at the end of the plugin's first line.",
+          "$ref": "#/$defs/comment"
+        },
+        "endComments": {
+          "description": "The comment lines before the plugin's closing brace.",
+          "$ref": "#/$defs/comments"
+        },
+        "endComment": {
+          "description": "The comment at the end of the plugin's closing brace line.",
+          "$ref": "#/$defs/comment"
+        }
+      }
+    },
+    "option": {
+      "description": "An option of a plugin.",
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": { "$ref": "#/$defs/name" },
+        "args": { "$ref": "#/$defs/args" },
+        "comments": {
+          "description": "The comment lines preceding the option.",
+          "$ref": "#/$defs/comments"
+        },
+        "comment": {
+          "description": "The comment at the end of the option's line.",
+          "$ref": "#/$defs/comment"
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,353 @@
+// Package deployment cross-checks a Corefile against the Kubernetes Deployment or DaemonSet running CoreDNS, e.g.
+// probes targeting ports the health and ready plugins do not listen on, or files outside of any volume mount.
+package deployment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/corefile"
+	"github.com/coredns/corefile-migration/migration/lint"
+)
+
+// Finding is a mismatch between a Corefile and the manifest running it.
+type Finding struct {
+	Check      string // the name of the check raising the finding, e.g. "health-probe"
+	Severity   lint.Severity
+	Server     string // the server block, e.g. ".:53", if the finding is about a server block
+	Plugin     string // the plugin name, if the finding is about a plugin
+	Option     string // the option name, if the finding is about an option
+	Message    string
+	Suggestion string // the manifest change resolving the finding, if any
+}
+
+func (f *Finding) ToString() string {
+	loc := []string{}
+	if f.Server != "" {
+		loc = append(loc, fmt.Sprintf(`server block "%v"`, f.Server))
+	}
+	if f.Plugin != "" {
+		loc = append(loc, fmt.Sprintf(`plugin "%v"`, f.Plugin))
+	}
+	if f.Option != "" {
+		loc = append(loc, fmt.Sprintf(`option "%v"`, f.Option))
+	}
+	s := fmt.Sprintf("[%v] %v: ", f.Severity, f.Check)
+	if len(loc) > 0 {
+		s += strings.Join(loc, ", ") + ": "
+	}
+	s += f.Message
+	if f.Suggestion != "" {
+		s += "\n    suggestion: " + f.Suggestion
+	}
+	return s
+}
+
+// Check returns the mismatches between the Corefile and the CoreDNS container of the manifest (see
+// Manifest.CoreDNSContainer).
+func Check(cf *corefile.Corefile, m *Manifest) []Finding {
+	c := m.CoreDNSContainer()
+	if c == nil {
+		return []Finding{{
+			Check:    "container",
+			Severity: lint.SevError,
+			Message:  fmt.Sprintf("%v %q has no containers", m.Kind, m.Name),
+		}}
+	}
+	findings := []Finding{}
+	findings = append(findings, checkDNSPorts(cf, c)...)
+	findings = append(findings, checkProbe(cf, c, healthProbe)...)
+	findings = append(findings, checkProbe(cf, c, readyProbe)...)
+	findings = append(findings, checkMetricsPorts(cf, c)...)
+	findings = append(findings, checkFiles(cf, c)...)
+	return findings
+}
+
+// CheckString parses the Corefile and the YAML manifest, and returns their mismatches.  If toCoreDNSVersion is set,
+// the Corefile is first migrated from the CoreDNS version of the container image to toCoreDNSVersion, so the findings
+// are the manifest changes needed for the target version, including the image itself.
+func CheckString(corefileStr string, manifest []byte, toCoreDNSVersion string) ([]Finding, error) {
+	m, err := Parse(manifest)
+	if err != nil {
+		return nil, err
+	}
+	findings := []Finding{}
+	if c := m.CoreDNSContainer(); c != nil && toCoreDNSVersion != "" {
+		from, err := migration.VersionFromImage(c.Image)
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine the CoreDNS version of container %q: %w", c.Name, err)
+		}
+		to, err := migration.ResolveVersion(toCoreDNSVersion)
+		if err != nil {
+			return nil, err
+		}
+		if from != to {
+			corefileStr, err = migration.Migrate(from, to, corefileStr, true)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, Finding{
+				Check:      "image",
+				Severity:   lint.SevWarning,
+				Message:    fmt.Sprintf("container %q runs CoreDNS %v, but the Corefile is migrated to %v", c.Name, from, to),
+				Suggestion: fmt.Sprintf("set the image of container %q to %v", c.Name, upgradedImage(c.Image, to)),
+			})
+		}
+	}
+	cf, err := corefile.New(corefileStr)
+	if err != nil {
+		return nil, err
+	}
+	return append(findings, Check(cf, m)...), nil
+}
+
+// upgradedImage returns the image reference with its tag set to the CoreDNS version, in the style of the original tag.
+// The digest is dropped, since it belongs to the original release.
+func upgradedImage(image, version string) string {
+	ref, err := migration.ParseImageRef(image)
+	if err != nil || ref.Repository == "" {
+		return "coredns/coredns:" + version
+	}
+	if ref.Tag == "" || strings.HasPrefix(ref.Tag, "v") {
+		ref.Tag = "v" + version
+	} else {
+		ref.Tag = version
+	}
+	ref.Digest = ""
+	return ref.String()
+}
+
+// checkDNSPorts finds server block ports not exposed by the container.
+func checkDNSPorts(cf *corefile.Corefile, c *Container) []Finding {
+	findings := []Finding{}
+	seen := map[string]bool{}
+	for _, s := range cf.Servers {
+		for _, dp := range s.DomPorts {
+			port, protocols := serverPort(dp)
+			missing := []string{}
+			for _, proto := range protocols {
+				if !seen[port+"/"+proto] && !c.exposes(port, proto) {
+					missing = append(missing, proto)
+				}
+				seen[port+"/"+proto] = true
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			findings = append(findings, Finding{
+				Check:      "dns-port",
+				Severity:   lint.SevError,
+				Server:     s.Name(),
+				Message:    fmt.Sprintf("the server block listens on port %v, but container %q does not expose it over %v", port, c.Name, strings.Join(missing, " and ")),
+				Suggestion: fmt.Sprintf("add containerPort %v with protocol %v to container %q", port, strings.Join(missing, " and "), c.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// serverPort returns the port of a server block key, and the protocols it is served over.
+func serverPort(dp string) (string, []string) {
+	sa := corefile.ParseServerAddr(dp)
+	switch sa.Scheme {
+	case "dns":
+		return sa.Port, []string{"UDP", "TCP"}
+	case "quic":
+		return sa.Port, []string{"UDP"}
+	}
+	return sa.Port, []string{"TCP"}
+}
+
+// probeCheck describes the check of a probe targeting a plugin's HTTP endpoint.
+type probeCheck struct {
+	name        string // the name of the check
+	plugin      string // the plugin serving the endpoint
+	path        string // the path of the endpoint
+	defaultAddr string // the address the plugin listens on by default
+	field       string // the name of the probe in the container spec
+	probe       func(c *Container) *Probe
+}
+
+var healthProbe = probeCheck{
+	name:        "health-probe",
+	plugin:      "health",
+	path:        "/health",
+	defaultAddr: ":8080",
+	field:       "livenessProbe",
+	probe:       func(c *Container) *Probe { return c.LivenessProbe },
+}
+
+var readyProbe = probeCheck{
+	name:        "ready-probe",
+	plugin:      "ready",
+	path:        "/ready",
+	defaultAddr: ":8181",
+	field:       "readinessProbe",
+	probe:       func(c *Container) *Probe { return c.ReadinessProbe },
+}
+
+// checkProbe finds probes targeting a plugin missing from the Corefile, or listening on another port.
+func checkProbe(cf *corefile.Corefile, c *Container, pc probeCheck) []Finding {
+	s, p := findPlugin(cf, pc.plugin)
+	var httpGet *HTTPGetAction
+	if probe := pc.probe(c); probe != nil {
+		httpGet = probe.HTTPGet
+	}
+	if httpGet == nil || httpGet.Path != pc.path {
+		if p == nil {
+			return nil
+		}
+		return []Finding{{
+			Check:      pc.name,
+			Severity:   lint.SevInfo,
+			Server:     s.Name(),
+			Plugin:     p.Name,
+			Message:    fmt.Sprintf("no %v of container %q targets %v", pc.field, c.Name, pc.path),
+			Suggestion: fmt.Sprintf("add a %v with httpGet path %v and port %v to container %q", pc.field, pc.path, addrPort(p, pc.defaultAddr), c.Name),
+		}}
+	}
+	if p == nil {
+		return []Finding{{
+			Check:      pc.name,
+			Severity:   lint.SevError,
+			Plugin:     pc.plugin,
+			Message:    fmt.Sprintf("the %v of container %q targets %v, but the Corefile has no %v plugin", pc.field, c.Name, pc.path, pc.plugin),
+			Suggestion: fmt.Sprintf("add the %v plugin to the Corefile, or remove the %v from container %q", pc.plugin, pc.field, c.Name),
+		}}
+	}
+	port := addrPort(p, pc.defaultAddr)
+	probePort, ok := c.resolvePort(httpGet.Port)
+	if ok && probePort == port {
+		return nil
+	}
+	msg := fmt.Sprintf("the %v plugin listens on port %v, but the %v of container %q targets port %v", pc.plugin, port, pc.field, c.Name, probePort)
+	if !ok {
+		msg = fmt.Sprintf("the %v of container %q targets unknown port %q", pc.field, c.Name, httpGet.Port)
+	}
+	return []Finding{{
+		Check:      pc.name,
+		Severity:   lint.SevError,
+		Server:     s.Name(),
+		Plugin:     p.Name,
+		Message:    msg,
+		Suggestion: fmt.Sprintf("set %v.httpGet.port of container %q to %v", pc.field, c.Name, port),
+	}}
+}
+
+// checkMetricsPorts finds prometheus ports not exposed by the container.
+func checkMetricsPorts(cf *corefile.Corefile, c *Container) []Finding {
+	findings := []Finding{}
+	seen := map[string]bool{}
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			if p.Name != "prometheus" {
+				continue
+			}
+			port := addrPort(p, ":9153")
+			if seen[port] {
+				continue
+			}
+			seen[port] = true
+			if c.exposes(port, "TCP") {
+				continue
+			}
+			findings = append(findings, Finding{
+				Check:      "metrics-port",
+				Severity:   lint.SevWarning,
+				Server:     s.Name(),
+				Plugin:     p.Name,
+				Message:    fmt.Sprintf("metrics are served on port %v, but container %q does not expose it", port, c.Name),
+				Suggestion: fmt.Sprintf("add containerPort %v with protocol TCP named metrics to container %q", port, c.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// providedFiles are files provided by the kubelet to every container.
+var providedFiles = map[string]bool{"/etc/resolv.conf": true, "/etc/hosts": true}
+
+// checkFiles finds files referenced by the Corefile outside of any volume mount of the container.
+func checkFiles(cf *corefile.Corefile, c *Container) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			for _, f := range pluginFiles(p) {
+				if !strings.HasPrefix(f.path, "/") || providedFiles[f.path] || c.mounts(f.path) {
+					continue
+				}
+				findings = append(findings, Finding{
+					Check:      "volume-mount",
+					Severity:   lint.SevError,
+					Server:     s.Name(),
+					Plugin:     p.Name,
+					Option:     f.option,
+					Message:    fmt.Sprintf("%v is not in any volume mount of container %q", f.path, c.Name),
+					Suggestion: fmt.Sprintf("mount a volume holding %v into container %q", f.path, c.Name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// fileRef is a file referenced by a plugin or option.
+type fileRef struct {
+	option string
+	path   string
+}
+
+// pluginFiles returns the files referenced by the plugin and its options.
+func pluginFiles(p *corefile.Plugin) []fileRef {
+	refs := []fileRef{}
+	add := func(option string, paths ...string) {
+		for _, path := range paths {
+			refs = append(refs, fileRef{option: option, path: path})
+		}
+	}
+	switch p.Name {
+	case "tls":
+		add("", p.Args...)
+	case "file", "sign":
+		if len(p.Args) > 0 {
+			add("", p.Args[0])
+		}
+	case "hosts":
+		add("", migration.HostsFile(p))
+	}
+	for _, o := range p.Options {
+		switch {
+		case o.Name == "tls" && (p.Name == "kubernetes" || p.Name == "forward" || p.Name == "grpc" || p.Name == "etcd"):
+			add(o.Name, o.Args...)
+		case o.Name == "kubeconfig" && p.Name == "kubernetes" && len(o.Args) > 0:
+			add(o.Name, o.Args[0])
+		case o.Name == "directory" && p.Name == "auto" && len(o.Args) > 0:
+			add(o.Name, o.Args[0])
+		case o.Name == "key" && p.Name == "dnssec" && len(o.Args) > 1 && o.Args[0] == "file":
+			add(o.Name, o.Args[1:]...)
+		}
+	}
+	return refs
+}
+
+// findPlugin returns the first plugin named name, and its server block.
+func findPlugin(cf *corefile.Corefile, name string) (*corefile.Server, *corefile.Plugin) {
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			if p.Name == name {
+				return s, p
+			}
+		}
+	}
+	return nil, nil
+}
+
+// addrPort returns the port of the address the plugin listens on, given as its first argument.
+func addrPort(p *corefile.Plugin, defaultAddr string) string {
+	addr := defaultAddr
+	if len(p.Args) > 0 {
+		addr = p.Args[0]
+	}
+	return addr[strings.LastIndex(addr, ":")+1:]
+}
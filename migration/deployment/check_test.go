@@ -0,0 +1,193 @@
+package deployment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// kubeadmManifest is a trimmed down version of the CoreDNS manifest deployed by kubeadm.
+const kubeadmManifest = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: coredns
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: coredns
+  namespace: kube-system
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: coredns
+        image: registry.k8s.io/coredns/coredns:v1.8.6
+        args: [ "-conf", "/etc/coredns/Corefile" ]
+        volumeMounts:
+        - name: config-volume
+          mountPath: /etc/coredns
+          readOnly: true
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        - containerPort: 9153
+          name: metrics
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            path: /health
+            port: 8080
+            scheme: HTTP
+        readinessProbe:
+          httpGet:
+            path: /ready
+            port: 8181
+            scheme: HTTP
+      volumes:
+      - name: config-volume
+        configMap:
+          name: coredns
+`
+
+const kubeadmCorefile = `.:53 {
+    errors
+    health {
+       lameduck 5s
+    }
+    ready
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+       pods insecure
+       fallthrough in-addr.arpa ip6.arpa
+       ttl 30
+    }
+    prometheus :9153
+    forward . /etc/resolv.conf {
+       max_concurrent 1000
+    }
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(kubeadmManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Kind != "Deployment" || m.Name != "coredns" {
+		t.Errorf("expected Deployment coredns, got %v %v", m.Kind, m.Name)
+	}
+	c := m.CoreDNSContainer()
+	if c == nil {
+		t.Fatal("expected a coredns container")
+	}
+	if c.Image != "registry.k8s.io/coredns/coredns:v1.8.6" || len(c.Ports) != 3 || len(c.VolumeMounts) != 1 {
+		t.Errorf("unexpected container %+v", c)
+	}
+	if c.LivenessProbe == nil || c.LivenessProbe.HTTPGet == nil || c.LivenessProbe.HTTPGet.Port != "8080" {
+		t.Errorf("unexpected liveness probe %+v", c.LivenessProbe)
+	}
+	if len(m.Volumes) != 1 || m.Volumes[0].Name != "config-volume" {
+		t.Errorf("unexpected volumes %+v", m.Volumes)
+	}
+
+	_, err = Parse([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	if !errors.Is(err, ErrNoWorkload) {
+		t.Errorf("expected ErrNoWorkload, got %v", err)
+	}
+}
+
+func TestCheckString(t *testing.T) {
+	tests := []struct {
+		name     string
+		corefile string
+		manifest string
+		to       string
+		expected []string
+	}{
+		{
+			name:     "kubeadm",
+			corefile: kubeadmCorefile,
+			manifest: kubeadmManifest,
+		},
+		{
+			name: "mismatches",
+			corefile: `.:5353 {
+    errors
+    health :8081
+    kubernetes cluster.local {
+        kubeconfig /etc/kube/kubeconfig
+    }
+    prometheus :9253
+    forward . /etc/resolv.conf
+    hosts /etc/coredns/hosts
+}
+`,
+			manifest: strings.Replace(kubeadmManifest, "port: 8080", "port: dns-tcp", 1),
+			expected: []string{
+				`[error] dns-port: server block ".:5353": the server block listens on port 5353, but container "coredns" does not expose it over UDP and TCP
+    suggestion: add containerPort 5353 with protocol UDP and TCP to container "coredns"`,
+				`[error] health-probe: server block ".:5353", plugin "health": the health plugin listens on port 8081, but the livenessProbe of container "coredns" targets port 53
+    suggestion: set livenessProbe.httpGet.port of container "coredns" to 8081`,
+				`[error] ready-probe: plugin "ready": the readinessProbe of container "coredns" targets /ready, but the Corefile has no ready plugin
+    suggestion: add the ready plugin to the Corefile, or remove the readinessProbe from container "coredns"`,
+				`[warning] metrics-port: server block ".:5353", plugin "prometheus": metrics are served on port 9253, but container "coredns" does not expose it
+    suggestion: add containerPort 9253 with protocol TCP named metrics to container "coredns"`,
+				`[error] volume-mount: server block ".:5353", plugin "kubernetes", option "kubeconfig": /etc/kube/kubeconfig is not in any volume mount of container "coredns"
+    suggestion: mount a volume holding /etc/kube/kubeconfig into container "coredns"`,
+			},
+		},
+		{
+			name: "target version",
+			corefile: `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`,
+			manifest: strings.Replace(strings.Replace(kubeadmManifest, "registry.k8s.io/coredns/coredns:v1.8.6", "k8s.gcr.io/coredns:1.3.1", 1), "path: /ready\n            port: 8181", "path: /health\n            port: 8080", 1),
+			to:       "1.6.7",
+			expected: []string{
+				`[warning] image: container "coredns" runs CoreDNS 1.3.1, but the Corefile is migrated to 1.6.7
+    suggestion: set the image of container "coredns" to k8s.gcr.io/coredns:1.6.7`,
+				`[info] ready-probe: server block ".:53", plugin "ready": no readinessProbe of container "coredns" targets /ready
+    suggestion: add a readinessProbe with httpGet path /ready and port 8181 to container "coredns"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings, err := CheckString(test.corefile, []byte(test.manifest), test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, f := range findings {
+				got = append(got, f.ToString())
+			}
+			if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("Expected:\n%v\nGot:\n%v", strings.Join(test.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
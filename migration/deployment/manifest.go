@@ -0,0 +1,164 @@
+package deployment
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoWorkload is returned when a manifest has no Deployment or DaemonSet.
+var ErrNoWorkload = errors.New("no Deployment or DaemonSet found in the manifest")
+
+// Manifest is the part of a Deployment or DaemonSet running CoreDNS relevant to its Corefile.
+type Manifest struct {
+	Kind       string // "Deployment" or "DaemonSet"
+	Name       string
+	Containers []Container
+	Volumes    []Volume
+}
+
+// Container is a container of the pod template of a Manifest.
+type Container struct {
+	Name           string        `yaml:"name"`
+	Image          string        `yaml:"image"`
+	Args           []string      `yaml:"args"`
+	Ports          []Port        `yaml:"ports"`
+	LivenessProbe  *Probe        `yaml:"livenessProbe"`
+	ReadinessProbe *Probe        `yaml:"readinessProbe"`
+	VolumeMounts   []VolumeMount `yaml:"volumeMounts"`
+}
+
+// Port is a port exposed by a Container.
+type Port struct {
+	Name          string `yaml:"name"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol"` // "TCP" if empty
+}
+
+// Probe is a liveness or readiness probe of a Container.  Only HTTP probes are checked.
+type Probe struct {
+	HTTPGet *HTTPGetAction `yaml:"httpGet"`
+}
+
+// HTTPGetAction is the HTTP request of a Probe.
+type HTTPGetAction struct {
+	Path string      `yaml:"path"`
+	Port IntOrString `yaml:"port"`
+}
+
+// IntOrString is a port given by number or by name.
+type IntOrString string
+
+// UnmarshalYAML accepts both numbers and strings.
+func (s *IntOrString) UnmarshalYAML(value *yaml.Node) error {
+	*s = IntOrString(value.Value)
+	return nil
+}
+
+// VolumeMount is a volume mounted into a Container.
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+}
+
+// Volume is a volume of the pod template of a Manifest.
+type Volume struct {
+	Name string `yaml:"name"`
+}
+
+// workload is the layout of a Deployment or DaemonSet.
+type workload struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Template struct {
+			Spec struct {
+				Containers []Container `yaml:"containers"`
+				Volumes    []Volume    `yaml:"volumes"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+// Parse returns the first Deployment or DaemonSet in the YAML manifest, which may hold several documents.
+func Parse(data []byte) (*Manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var w workload
+		err := dec.Decode(&w)
+		if err == io.EOF {
+			return nil, ErrNoWorkload
+		}
+		if err != nil {
+			return nil, err
+		}
+		if w.Kind != "Deployment" && w.Kind != "DaemonSet" {
+			continue
+		}
+		return &Manifest{
+			Kind:       w.Kind,
+			Name:       w.Metadata.Name,
+			Containers: w.Spec.Template.Spec.Containers,
+			Volumes:    w.Spec.Template.Spec.Volumes,
+		}, nil
+	}
+}
+
+// CoreDNSContainer returns the container named "coredns", or the first container if there is none.
+func (m *Manifest) CoreDNSContainer() *Container {
+	for i := range m.Containers {
+		if m.Containers[i].Name == "coredns" {
+			return &m.Containers[i]
+		}
+	}
+	if len(m.Containers) == 0 {
+		return nil
+	}
+	return &m.Containers[0]
+}
+
+// resolvePort returns the number of the port, given by number or by the name of one of the container's ports.
+func (c *Container) resolvePort(port IntOrString) (string, bool) {
+	for _, p := range c.Ports {
+		if p.Name != "" && p.Name == string(port) {
+			return strconv.Itoa(p.ContainerPort), true
+		}
+	}
+	for _, ch := range port {
+		if ch < '0' || ch > '9' {
+			return "", false
+		}
+	}
+	return string(port), port != ""
+}
+
+// exposes returns true if the container exposes the port with the protocol.
+func (c *Container) exposes(port, protocol string) bool {
+	for _, p := range c.Ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "TCP"
+		}
+		if strconv.Itoa(p.ContainerPort) == port && proto == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// mounts returns true if the path is inside one of the container's volume mounts.
+func (c *Container) mounts(path string) bool {
+	for _, vm := range c.VolumeMounts {
+		mp := strings.TrimSuffix(vm.MountPath, "/")
+		if path == mp || strings.HasPrefix(path, mp+"/") {
+			return true
+		}
+	}
+	return false
+}
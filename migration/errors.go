@@ -0,0 +1,136 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownVersion is returned when a CoreDNS version is not in the release catalog.
+	ErrUnknownVersion = errors.New("unsupported CoreDNS version")
+	// ErrUnknownKubernetesVersion is returned when a Kubernetes version is not in the release catalog.
+	ErrUnknownKubernetesVersion = errors.New("unsupported Kubernetes version")
+	// ErrInvalidDirection is returned when the destination version cannot be reached in the requested direction.
+	ErrInvalidDirection = errors.New("invalid migration direction")
+	// ErrUnhandledServerBlock is returned when a server block cannot be migrated.
+	ErrUnhandledServerBlock = errors.New("unhandled migration of server block")
+	// ErrUnknownSHA is returned when a docker image SHA does not match any release.
+	ErrUnknownSHA = errors.New("sha unsupported")
+	// ErrNotCoreDNSImage is returned when an image reference does not name a CoreDNS repository.
+	ErrNotCoreDNSImage = errors.New("not a CoreDNS image")
+	// ErrImageMismatch is returned when the tag and digest of an image reference belong to different releases.
+	ErrImageMismatch = errors.New("image tag and digest mismatch")
+	// ErrUnsupported is returned when a plugin/option is not supported by the migration tool, and the migration is
+	// configured to fail on unknown plugins/options.
+	ErrUnsupported = errors.New("not supported by the migration tool")
+	// ErrRemoved is returned when a plugin/option has been removed from the CoreDNS version a Corefile is built for.
+	ErrRemoved = errors.New("removed in CoreDNS")
+	// ErrIgnored is returned when a plugin/option is ignored by the CoreDNS version a Corefile is built for.
+	ErrIgnored = errors.New("ignored by CoreDNS")
+	// ErrServerBlockSplit is returned when a migration would split server blocks, and the migration is configured
+	// not to.
+	ErrServerBlockSplit = errors.New("migration requires splitting server blocks")
+	// ErrInvalidKubeDNSConfig is returned when a kube-dns ConfigMap cannot be parsed.
+	ErrInvalidKubeDNSConfig = errors.New("invalid kube-dns configuration")
+	// ErrInvalidHostsEntry is returned when an inline entry of a hosts plugin cannot be parsed.
+	ErrInvalidHostsEntry = errors.New("invalid hosts entry")
+	// ErrInvalidStatus is returned when a support status cannot be set in a release catalog.
+	ErrInvalidStatus = errors.New("invalid catalog status")
+	// ErrAbortSeverity is returned when a migration raises notices at or above the configured abort severity.
+	ErrAbortSeverity = errors.New("migration aborted")
+)
+
+// MigrationError is an error raised while migrating a specific part of a Corefile.
+type MigrationError struct {
+	Version string // the CoreDNS version being migrated to
+	Server  string // the server block, e.g. ".:53"
+	Plugin  string // the plugin name
+	Option  string // the option name
+	Rule    string // the form of the rewrite rule, e.g. "name regex"
+	Err     error
+}
+
+func (e *MigrationError) Error() string {
+	var loc []string
+	if e.Version != "" {
+		loc = append(loc, "migrating to "+e.Version)
+	}
+	if e.Server != "" {
+		loc = append(loc, fmt.Sprintf("server block '%v'", e.Server))
+	}
+	if e.Plugin != "" {
+		loc = append(loc, fmt.Sprintf("plugin '%v'", e.Plugin))
+	}
+	if e.Option != "" {
+		loc = append(loc, fmt.Sprintf("option '%v'", e.Option))
+	}
+	if e.Rule != "" {
+		loc = append(loc, fmt.Sprintf("rule '%v'", e.Rule))
+	}
+	if len(loc) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(loc, ", ") + ": " + e.Err.Error()
+}
+
+func (e *MigrationError) Unwrap() error { return e.Err }
+
+// MigrationErrors is a list of errors collected by a migration that continues on errors.
+type MigrationErrors []*MigrationError
+
+func (e MigrationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (e MigrationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target.
+func (e MigrationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// add appends err to the list, setting the migration version on it.
+func (e *MigrationErrors) add(err error, version string) {
+	if errs, ok := err.(MigrationErrors); ok {
+		for _, mErr := range errs {
+			e.add(mErr, version)
+		}
+		return
+	}
+	var mErr *MigrationError
+	if !errors.As(err, &mErr) {
+		mErr = &MigrationError{Err: err}
+	}
+	if mErr.Version == "" {
+		mErr.Version = version
+	}
+	*e = append(*e, mErr)
+}
+
+// withVersion sets the migration version on err, wrapping it in a MigrationError if it is not one already.
+func withVersion(err error, version string) error {
+	var errs MigrationErrors
+	errs.add(err, version)
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errs
+}
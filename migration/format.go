@@ -0,0 +1,111 @@
+package migration
+
+import (
+	"sort"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// FormatOptions are the options for formatting a Corefile.
+type FormatOptions struct {
+	SortPlugins bool // put the plugins of each server block in the order of CoreDNS's plugin.cfg
+}
+
+// pluginOrder is the order of plugins in CoreDNS's plugin.cfg, which is the order plugins are executed in regardless
+// of their order in the Corefile.  Plugins no longer shipped with CoreDNS are kept at their last position.
+var pluginOrder = []string{
+	"root",
+	"metadata",
+	"geoip",
+	"cancel",
+	"tls",
+	"quic",
+	"timeouts",
+	"multisocket",
+	"reload",
+	"nsid",
+	"bufsize",
+	"bind",
+	"debug",
+	"trace",
+	"ready",
+	"health",
+	"pprof",
+	"prometheus",
+	"errors",
+	"log",
+	"dnstap",
+	"local",
+	"dns64",
+	"acl",
+	"any",
+	"chaos",
+	"loadbalance",
+	"tsig",
+	"cache",
+	"rewrite",
+	"header",
+	"dnssec",
+	"autopath",
+	"minimal",
+	"template",
+	"transfer",
+	"hosts",
+	"route53",
+	"azure",
+	"clouddns",
+	"k8s_external",
+	"kubernetes",
+	"federation",
+	"file",
+	"auto",
+	"secondary",
+	"etcd",
+	"loop",
+	"forward",
+	"proxy",
+	"grpc",
+	"erratic",
+	"whoami",
+	"on",
+	"sign",
+	"view",
+	"nomad",
+}
+
+// PluginOrder returns the order of plugins in CoreDNS's plugin.cfg.
+func PluginOrder() []string {
+	return append([]string(nil), pluginOrder...)
+}
+
+// Format returns the Corefile in canonical style, keeping its comments.
+func Format(corefileStr string, opts FormatOptions) (string, error) {
+	cf, err := corefile.New(corefileStr)
+	if err != nil {
+		return "", err
+	}
+	if opts.SortPlugins {
+		for _, s := range cf.Servers {
+			sortPlugins(s)
+		}
+	}
+	return cf.ToString(), nil
+}
+
+// sortPlugins puts the plugins of the server block in plugin.cfg order.  Plugins unknown to plugin.cfg keep their
+// relative order after the known plugins.
+func sortPlugins(s *corefile.Server) {
+	rank := make(map[string]int, len(pluginOrder))
+	for i, name := range pluginOrder {
+		rank[name] = i
+	}
+	pos := func(p *corefile.Plugin) int {
+		if r, ok := rank[p.Name]; ok {
+			return r
+		}
+		return len(pluginOrder)
+	}
+	sort.SliceStable(s.Plugins, func(i, j int) bool {
+		return pos(s.Plugins[i]) < pos(s.Plugins[j])
+	})
+}
@@ -0,0 +1,118 @@
+package migration
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		corefile string
+		opts     FormatOptions
+		expected string
+	}{
+		{
+			name: "keep order",
+			corefile: `.:53 {
+  forward . /etc/resolv.conf
+  # cache for 30s
+  cache 30
+  errors
+}`,
+			expected: `.:53 {
+    forward . /etc/resolv.conf
+    # cache for 30s
+    cache 30
+    errors
+}
+`,
+		},
+		{
+			name: "plugin.cfg order",
+			corefile: `.:53 {
+    forward . /etc/resolv.conf
+    # cache for 30s
+    cache 30
+    myplugin
+    kubernetes cluster.local {
+        pods insecure
+    }
+    otherplugin
+    errors
+    health
+}`,
+			opts: FormatOptions{SortPlugins: true},
+			expected: `.:53 {
+    health
+    errors
+    # cache for 30s
+    cache 30
+    kubernetes cluster.local {
+        pods insecure
+    }
+    forward . /etc/resolv.conf
+    myplugin
+    otherplugin
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Format(test.corefile, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Fatalf("Expected:\n%v\nGot:\n%v", test.expected, got)
+			}
+			again, err := Format(got, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != got {
+				t.Errorf("Formatting is not stable.\nFirst:\n%v\nSecond:\n%v", got, again)
+			}
+		})
+	}
+}
+
+func TestMigrateKeepsComments(t *testing.T) {
+	startCorefile := `# cluster DNS
+.:53 {
+    errors
+    # k8s
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream # to be removed
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    proxy . /etc/resolv.conf
+    cache 30
+}
+`
+	expected := `# cluster DNS
+.:53 {
+    errors
+    # k8s
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+    cache 30
+    loop
+    ready
+}
+`
+	got, err := Migrate("1.2.0", "1.7.0", startCorefile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, got)
+	}
+}
@@ -0,0 +1,261 @@
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/corefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoServers is returned when the chart values have no "servers" list.
+var ErrNoServers = errors.New(`no "servers" list found in the values`)
+
+// MigrateValues migrates the servers of the chart values from fromCoreDNSVersion to toCoreDNSVersion, with the same
+// options as migration.MigrateWithOptions, and returns the values with everything else preserved.  Only the lines of
+// the "servers" list are rewritten, with the indentation of the list: the rest of the values keeps its bytes,
+// including indentation, quoting and comments.  Servers and plugins left unchanged by the migration keep their
+// content and comments, but take the indentation of the list.  If opts.ContinueOnError is set, the partially migrated
+// values are returned along with the migration.MigrationErrors.
+func MigrateValues(fromCoreDNSVersion, toCoreDNSVersion string, values []byte, opts migration.MigrateOptions) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(values, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, ErrNoServers
+	}
+	root := doc.Content[0]
+	serversNode := mappingValue(root, "servers")
+	if serversNode == nil || serversNode.Kind != yaml.SequenceNode {
+		return nil, ErrNoServers
+	}
+	var servers []Server
+	if err := serversNode.Decode(&servers); err != nil {
+		return nil, err
+	}
+
+	// the servers as rendered through a Corefile, to tell which servers and plugins the migration changes
+	cf, err := ToCorefile(servers)
+	if err != nil {
+		return nil, err
+	}
+	orig, err := FromCorefile(cf)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated, migrateErr := migration.MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, cf.ToString(), opts)
+	var errs migration.MigrationErrors
+	if migrateErr != nil && !errors.As(migrateErr, &errs) {
+		return nil, migrateErr
+	}
+	cf, err = corefile.New(migrated)
+	if err != nil {
+		return nil, err
+	}
+	newServers, err := FromCorefile(cf)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateServers(serversNode, orig, newServers); err != nil {
+		return nil, err
+	}
+
+	if root.Style&yaml.FlowStyle != 0 {
+		// the servers share their lines with the rest of the values
+		b, err := encode(&doc, 2)
+		if err != nil {
+			return nil, err
+		}
+		return b, migrateErr
+	}
+	b, err := patchServers(values, root)
+	if err != nil {
+		return nil, err
+	}
+	return b, migrateErr
+}
+
+// patchServers returns the values with the lines of the "servers" entry of the root block mapping replaced by the
+// encoding of the entry, keeping the bytes of every other line.
+func patchServers(values []byte, root *yaml.Node) ([]byte, error) {
+	i := 0
+	for root.Content[i].Value != "servers" {
+		i += 2
+	}
+	key := root.Content[i]
+	indent := key.Column - 1
+
+	lines := strings.SplitAfter(string(values), "\n")
+	start, end := key.Line-1, len(lines)
+	if i+2 < len(root.Content) {
+		end = root.Content[i+2].Line - 1
+	}
+	// blank lines and comments of the root mapping ending the entry stay where they are
+	for end > start+1 && outerLine(lines[end-1], indent) {
+		end--
+	}
+
+	// the servers list is indented as in the values
+	width := 2
+	for _, line := range lines[start+1 : end] {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if w := len(line) - len(strings.TrimLeft(line, " ")) - indent; w >= 2 {
+				width = w
+			}
+			break
+		}
+	}
+
+	entry := *key
+	entry.HeadComment = ""
+	b, err := encode(&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{&entry, root.Content[i+1]}}, width)
+	if err != nil {
+		return nil, err
+	}
+	encoded := strings.SplitAfter(string(b), "\n")
+	n := len(encoded)
+	for n > 1 && outerLine(encoded[n-1], 0) {
+		n--
+	}
+	if block := root.Content[i+1].Line > key.Line; block && len(encoded) > 1 {
+		// the list starts below the key, which keeps its line
+		start++
+		encoded = encoded[1:]
+		n--
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines[:start] {
+		buf.WriteString(line)
+	}
+	for _, line := range encoded[:n] {
+		if strings.TrimSpace(line) != "" {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString(line)
+	}
+	for _, line := range lines[end:] {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
+
+// outerLine returns true if the line is blank, or a comment indented at most indent spaces.
+func outerLine(line string, indent int) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#") && len(line)-len(strings.TrimLeft(line, " ")) <= indent
+}
+
+// encode returns the YAML encoding of the node, indented by indent spaces per level.
+func encode(node *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// updateServers updates the servers sequence node, holding the orig servers, to the new servers.
+func updateServers(node *yaml.Node, orig, servers []Server) error {
+	content := []*yaml.Node{}
+	used := make([]bool, len(orig))
+NextServer:
+	for _, s := range servers {
+		for i, o := range orig {
+			if used[i] || !sameKeys(o, s) {
+				continue
+			}
+			used[i] = true
+			if err := updatePlugins(node.Content[i], o.Plugins, s.Plugins); err != nil {
+				return err
+			}
+			content = append(content, node.Content[i])
+			continue NextServer
+		}
+		n, err := s.node()
+		if err != nil {
+			return err
+		}
+		content = append(content, n)
+	}
+	node.Content = content
+	return nil
+}
+
+// updatePlugins updates the plugins of the server mapping node, holding the orig plugins, to the new plugins.
+func updatePlugins(server *yaml.Node, orig, plugins []Plugin) error {
+	node := mappingValue(server, "plugins")
+	if node == nil {
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		server.Content = append(server.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "plugins"}, node)
+	}
+	content := []*yaml.Node{}
+	used := make([]bool, len(orig))
+NextPlugin:
+	for _, p := range plugins {
+		for i, o := range orig {
+			if used[i] || !samePlugin(o, p) || i >= len(node.Content) {
+				continue
+			}
+			used[i] = true
+			content = append(content, node.Content[i])
+			continue NextPlugin
+		}
+		n, err := p.node()
+		if err != nil {
+			return err
+		}
+		content = append(content, n)
+	}
+	node.Content = content
+	return nil
+}
+
+// samePlugin returns true if the plugins are the same, comments included.
+func samePlugin(a, b Plugin) bool {
+	if a.Name != b.Name || a.Parameters != b.Parameters || a.ConfigBlock != b.ConfigBlock || len(a.Comments) != len(b.Comments) {
+		return false
+	}
+	for i := range a.Comments {
+		if a.Comments[i] != b.Comments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameKeys returns true if the servers serve the same zones on the same port.
+func sameKeys(a, b Server) bool {
+	if a.Port != b.Port || len(a.Zones) != len(b.Zones) {
+		return false
+	}
+	for i := range a.Zones {
+		if a.Zones[i].Zone != b.Zones[i].Zone || a.Zones[i].Scheme != b.Zones[i].Scheme {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingValue returns the value of the key in the mapping node, or nil if there is none.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package helm converts between the Corefile and the "servers" list of the values of the CoreDNS Helm chart, and
+// migrates the Corefile embedded in chart values.
+package helm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Server is a server block in the chart values, e.g.
+//
+//	servers:
+//	- zones:
+//	  - zone: .
+//	  port: 53
+//	  plugins:
+//	  - name: errors
+//	  - name: forward
+//	    parameters: . /etc/resolv.conf
+//
+// Comments are the comments of the server block in the Corefile.  They are not part of the chart values, and are
+// written as a YAML comment on the server.
+type Server struct {
+	Zones    []Zone   `yaml:"zones"`
+	Port     int      `yaml:"port,omitempty"`
+	Plugins  []Plugin `yaml:"plugins"`
+	Comments []string `yaml:"-"`
+}
+
+// Zone is a zone served by a Server.
+type Zone struct {
+	Zone   string `yaml:"zone,omitempty"`   // "." if empty
+	Scheme string `yaml:"scheme,omitempty"` // e.g. "tls://", "dns://" if empty
+	UseTCP bool   `yaml:"use_tcp,omitempty"`
+}
+
+// Plugin is a plugin of a Server.  The parameters and the config block are given in Corefile syntax, the comments of
+// the plugin's options included in the config block.  Comments are the comments of the plugin itself in the Corefile,
+// written as a YAML comment on the plugin.
+type Plugin struct {
+	Name        string   `yaml:"name"`
+	Parameters  string   `yaml:"parameters,omitempty"`
+	ConfigBlock string   `yaml:"configBlock,omitempty"`
+	Comments    []string `yaml:"-"`
+}
+
+// MarshalYAML encodes the server with its comments, and those of its plugins, as the head comments of their nodes.
+func (s Server) MarshalYAML() (interface{}, error) {
+	return s.node()
+}
+
+func (s Server) node() (*yaml.Node, error) {
+	type plain Server
+	n, err := commentedNode(plain(s), s.Comments)
+	if err != nil {
+		return nil, err
+	}
+	// the comments of nested nodes do not survive encoding to a node, set them again
+	if plugins := mappingValue(n, "plugins"); plugins != nil {
+		for i, p := range s.Plugins {
+			if i < len(plugins.Content) {
+				plugins.Content[i].HeadComment = strings.Join(p.Comments, "\n")
+			}
+		}
+	}
+	return n, nil
+}
+
+// MarshalYAML encodes the plugin with its comments as the head comment of its node.
+func (p Plugin) MarshalYAML() (interface{}, error) {
+	return p.node()
+}
+
+func (p Plugin) node() (*yaml.Node, error) {
+	type plain Plugin
+	return commentedNode(plain(p), p.Comments)
+}
+
+func commentedNode(v interface{}, comments []string) (*yaml.Node, error) {
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	n.HeadComment = strings.Join(comments, "\n")
+	return n, nil
+}
+
+// ToCorefile returns the Corefile rendered by the chart from the servers.  As in the chart, the port is only added to
+// the last zone of a server.
+func ToCorefile(servers []Server) (*corefile.Corefile, error) {
+	var b strings.Builder
+	for i, s := range servers {
+		keys := []string{}
+		for _, z := range s.Zones {
+			zone := z.Zone
+			if zone == "" {
+				zone = "."
+			}
+			keys = append(keys, z.Scheme+zone)
+		}
+		if len(keys) == 0 {
+			keys = append(keys, ".")
+		}
+		if s.Port != 0 {
+			keys[len(keys)-1] += ":" + strconv.Itoa(s.Port)
+		}
+		writeComments(&b, s.Comments)
+		b.WriteString(strings.Join(keys, " ") + " {\n")
+		for _, p := range s.Plugins {
+			if p.Name == "" {
+				return nil, fmt.Errorf("server %d: plugin without a name", i)
+			}
+			writeComments(&b, p.Comments)
+			b.WriteString(p.Name)
+			if p.Parameters != "" {
+				b.WriteString(" " + p.Parameters)
+			}
+			if p.ConfigBlock != "" {
+				b.WriteString(" {\n" + p.ConfigBlock + "\n}")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n")
+	}
+	return corefile.New(b.String())
+}
+
+// writeComments writes the comments as Corefile comment lines.
+func writeComments(b *strings.Builder, comments []string) {
+	for _, c := range comments {
+		if !strings.HasPrefix(c, "#") {
+			c = "# " + c
+		}
+		b.WriteString(c + "\n")
+	}
+}
+
+// FromCorefile returns the servers of the chart values rendering the Corefile.  All keys of a server block must use
+// the same port, since the chart has a single port per server.  The comments of the server blocks and plugins are
+// kept in their Comments, and those of the options in the config blocks.
+func FromCorefile(cf *corefile.Corefile) ([]Server, error) {
+	servers := []Server{}
+	for _, s := range cf.Servers {
+		srv := Server{Comments: nodeComments(s.Comments, s.Comment, s.EndComments, s.EndComment)}
+		port := ""
+		for _, dp := range s.DomPorts {
+			scheme, zone, zonePort := corefile.SplitServerKey(dp)
+			z := Zone{Zone: zone}
+			if scheme != "" {
+				z.Scheme = scheme + "://"
+			}
+			if zonePort != "" {
+				if port != "" && port != zonePort {
+					return nil, fmt.Errorf("server block %q: keys on different ports are not supported by the chart", s.Name())
+				}
+				port = zonePort
+			}
+			srv.Zones = append(srv.Zones, z)
+		}
+		if port == "" {
+			port = defaultPort(srv)
+		}
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("server block %q: invalid port %q", s.Name(), port)
+		}
+		srv.Port = p
+		for _, p := range s.Plugins {
+			srv.Plugins = append(srv.Plugins, fromPlugin(p))
+		}
+		servers = append(servers, srv)
+	}
+	return servers, nil
+}
+
+// defaultPort returns the default port of the transport of the last zone of the server.
+func defaultPort(s Server) string {
+	scheme := "dns"
+	if len(s.Zones) > 0 && s.Zones[len(s.Zones)-1].Scheme != "" {
+		scheme = strings.TrimSuffix(s.Zones[len(s.Zones)-1].Scheme, "://")
+	}
+	return corefile.DefaultPort(scheme)
+}
+
+func fromPlugin(p *corefile.Plugin) Plugin {
+	plugin := Plugin{Name: p.Name, Comments: nodeComments(p.Comments, p.Comment, nil, p.EndComment)}
+	// render the arguments the way the Corefile does, quoting them where needed
+	args := (&corefile.Plugin{Name: p.Name, Args: p.Args}).ToString()
+	plugin.Parameters = strings.TrimPrefix(strings.TrimPrefix(args, p.Name), " ")
+	lines := []string{}
+	for _, o := range p.Options {
+		lines = append(lines, o.Comments...)
+		lines = append(lines, o.ToString())
+	}
+	lines = append(lines, p.EndComments...)
+	plugin.ConfigBlock = strings.Join(lines, "\n")
+	return plugin
+}
+
+// nodeComments returns all the comments of a server block or plugin: the comment lines preceding it, the comment at
+// the end of its first line, the comment lines before its closing brace, and the comment after its closing brace.
+func nodeComments(comments []string, comment string, endComments []string, endComment string) []string {
+	all := append([]string(nil), comments...)
+	if comment != "" {
+		all = append(all, comment)
+	}
+	all = append(all, endComments...)
+	if endComment != "" {
+		all = append(all, endComment)
+	}
+	return all
+}
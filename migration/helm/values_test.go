@@ -0,0 +1,331 @@
+package helm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/corefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCorefileConversion(t *testing.T) {
+	values := `- zones:
+  - zone: .
+  port: 53
+  plugins:
+  - name: errors
+  - name: health
+    configBlock: |-
+      lameduck 5s
+  - name: kubernetes
+    parameters: cluster.local in-addr.arpa ip6.arpa
+    configBlock: |-
+      pods insecure
+      fallthrough in-addr.arpa ip6.arpa
+      ttl 30
+  - name: cache
+    parameters: 30
+- zones:
+  - zone: example.org
+    scheme: tls://
+  - zone: example.net
+    scheme: tls://
+  port: 8853
+  plugins:
+  - name: template
+    parameters: ANY A
+    configBlock: answer "{{ .Name }} 60 IN A 127.0.0.1"
+`
+	corefile := `.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    cache 30
+}
+
+tls://example.org tls://example.net:8853 {
+    template ANY A {
+        answer "{{ .Name }} 60 IN A 127.0.0.1"
+    }
+}
+`
+	var servers []Server
+	if err := yaml.Unmarshal([]byte(values), &servers); err != nil {
+		t.Fatal(err)
+	}
+	cf, err := ToCorefile(servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cf.ToString(); got != corefile {
+		t.Errorf("Expected Corefile:\n%v\nGot:\n%v", corefile, got)
+	}
+
+	back, err := FromCorefile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := yaml.Marshal(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []Server
+	if err := yaml.Unmarshal(got, &expected); err != nil {
+		t.Fatal(err)
+	}
+	cf, err = ToCorefile(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := cf.ToString(); again != corefile {
+		t.Errorf("Expected Corefile after round trip:\n%v\nGot:\n%v", corefile, again)
+	}
+}
+
+func TestCorefileComments(t *testing.T) {
+	corefileStr := `# default server
+.:53 { # all zones
+    # log errors
+    errors
+    forward . /etc/resolv.conf { # upstream
+        # no more than 1000
+        max_concurrent 1000
+    } # end of forward
+} # end of server
+`
+	cf, err := corefile.New(corefileStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers, err := FromCorefile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := yaml.Marshal(servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# default server
+# all zones
+# end of server
+- zones:
+    - zone: .
+  port: 53
+  plugins:
+    # log errors
+    - name: errors
+    # upstream
+    # end of forward
+    - name: forward
+      parameters: . /etc/resolv.conf
+      configBlock: |-
+        # no more than 1000
+        max_concurrent 1000
+`
+	if string(got) != expected {
+		t.Errorf("Expected values:\n%v\nGot:\n%v", expected, string(got))
+	}
+
+	cf, err = ToCorefile(servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `# default server
+# all zones
+# end of server
+.:53 {
+    # log errors
+    errors
+    # upstream
+    # end of forward
+    forward . /etc/resolv.conf {
+        # no more than 1000
+        max_concurrent 1000
+    }
+}
+`
+	if got := cf.ToString(); got != expected {
+		t.Errorf("Expected Corefile:\n%v\nGot:\n%v", expected, got)
+	}
+}
+
+func TestFromCorefileErrors(t *testing.T) {
+	_, err := ToCorefile([]Server{{Plugins: []Plugin{{Parameters: "30"}}}})
+	if err == nil {
+		t.Error("expected an error for a plugin without a name")
+	}
+	cf, err := ToCorefile([]Server{{Zones: []Zone{{Zone: "example.org:53"}, {Zone: "example.net"}}, Port: 5353}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromCorefile(cf); err == nil {
+		t.Error("expected an error for keys on different ports")
+	}
+}
+
+func TestMigrateValues(t *testing.T) {
+	values := `# CoreDNS chart values
+replicaCount: 2
+image:
+  repository: coredns/coredns
+  tag: "1.3.1"
+servers:
+  - zones:
+      - zone: .
+        use_tcp: true
+    port: 53
+    # the plugins of the default server
+    plugins:
+      - name: errors
+      # health check
+      - name: health
+      - name: kubernetes
+        parameters: cluster.local in-addr.arpa ip6.arpa
+        configBlock: |-
+          pods insecure
+          upstream
+          fallthrough in-addr.arpa ip6.arpa
+      - name: proxy
+        parameters: example.org 1.2.3.4
+      - name: proxy
+        parameters: . /etc/resolv.conf
+      - name: cache
+        parameters: 30
+      - name: loop
+zoneFiles: []
+`
+	expected := `# CoreDNS chart values
+replicaCount: 2
+image:
+  repository: coredns/coredns
+  tag: "1.3.1"
+servers:
+  - zones:
+      - zone: .
+        use_tcp: true
+    port: 53
+    # the plugins of the default server
+    plugins:
+      - name: errors
+      # health check
+      - name: health
+      - name: kubernetes
+        parameters: cluster.local in-addr.arpa ip6.arpa
+        configBlock: |-
+          pods insecure
+          fallthrough in-addr.arpa ip6.arpa
+      - name: forward
+        parameters: . /etc/resolv.conf
+      - name: cache
+        parameters: 30
+      - name: loop
+      - name: ready
+  - zones:
+      - zone: example.org
+    port: 53
+    plugins:
+      - name: errors
+      - name: forward
+        parameters: . 1.2.3.4
+      - name: cache
+        parameters: "30"
+      - name: loop
+zoneFiles: []
+`
+	got, err := MigrateValues("1.3.1", "1.5.0", []byte(values), migration.MigrateOptions{Deprecations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, string(got))
+	}
+
+	_, err = MigrateValues("1.3.1", "1.5.0", []byte("replicaCount: 2\n"), migration.MigrateOptions{})
+	if !errors.Is(err, ErrNoServers) {
+		t.Errorf("expected ErrNoServers, got %v", err)
+	}
+}
+
+func TestMigrateValuesFormatting(t *testing.T) {
+	// everything but the servers list keeps its bytes: indentation, quoting, spacing, flow style and comments
+	head := `# CoreDNS chart values
+replicaCount:   2
+image:
+    repository: 'coredns/coredns'
+    tag: "1.3.1"   # pinned
+
+servers:  # the servers of the Corefile
+`
+	tail := `
+# zone files
+zoneFiles: []
+tolerations: [ {key: "CriticalAddonsOnly", operator: Exists} ]
+`
+	testCases := []struct {
+		name     string
+		values   string
+		expected string
+	}{
+		{
+			name: "servers before other values",
+			values: head + `    - zones:
+        - zone: .
+      port: 53
+      plugins:
+        # errors to stdout
+        - name: errors
+        - name: proxy
+          parameters: . /etc/resolv.conf
+` + tail,
+			expected: head + `    - zones:
+        - zone: .
+      port: 53
+      plugins:
+        # errors to stdout
+        - name: errors
+        - name: forward
+          parameters: . /etc/resolv.conf
+` + tail,
+		},
+		{
+			name: "servers last",
+			values: head + `    - zones:
+        - zone: .
+      port: 53
+      plugins:
+        - name: proxy
+          parameters: . /etc/resolv.conf
+
+# end of values
+`,
+			expected: head + `    - zones:
+        - zone: .
+      port: 53
+      plugins:
+        - name: forward
+          parameters: . /etc/resolv.conf
+
+# end of values
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MigrateValues("1.3.1", "1.5.0", []byte(tc.values), migration.MigrateOptions{Deprecations: true, SkipNewDefaults: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("Expected:\n%v\nGot:\n%v", tc.expected, string(got))
+			}
+		})
+	}
+}
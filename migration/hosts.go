@@ -0,0 +1,168 @@
+package migration
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// HostsEntry is an inline entry of a hosts plugin, i.e. a line of its block in the hosts file format, e.g.
+//
+//	hosts {
+//	    10.0.0.1 example.org www.example.org
+//	    fallthrough
+//	}
+type HostsEntry struct {
+	Addr  netip.Addr // the address, as given
+	Names []string   // the names, as given
+}
+
+// Hosts is a hosts plugin: its hosts file, zones, inline entries and other options, in order.
+type Hosts struct {
+	File    string             // the hosts file, "/etc/hosts" if not given
+	Zones   []string           // the zones of the plugin, if given
+	Entries []HostsEntry       // the inline entries
+	Options []*corefile.Option // the options other than inline entries, e.g. "fallthrough"
+}
+
+// DefaultHostsFile is the hosts file read by a hosts plugin without arguments.
+const DefaultHostsFile = "/etc/hosts"
+
+// hostsOptions are the options of the hosts plugin.  Any other line of its block is an inline entry, as CoreDNS reads
+// it.
+var hostsOptions = map[string]bool{"ttl": true, "no_reverse": true, "reload": true, "fallthrough": true}
+
+// IsHostsOption returns true if the name is the name of an option of the hosts plugin, rather than the address of an
+// inline entry.
+func IsHostsOption(name string) bool {
+	return hostsOptions[name]
+}
+
+// HostsFile returns the hosts file read by the hosts plugin.
+func HostsFile(p *corefile.Plugin) string {
+	if len(p.Args) == 0 {
+		return DefaultHostsFile
+	}
+	return p.Args[0]
+}
+
+// ParseHosts returns the hosts plugin, with its inline entries validated.
+func ParseHosts(p *corefile.Plugin) (*Hosts, error) {
+	h := &Hosts{File: HostsFile(p)}
+	if len(p.Args) > 1 {
+		h.Zones = p.Args[1:]
+	}
+	for _, o := range p.Options {
+		if IsHostsOption(o.Name) {
+			h.Options = append(h.Options, o)
+			continue
+		}
+		e, err := ParseHostsEntry(o)
+		if err != nil {
+			return nil, err
+		}
+		h.Entries = append(h.Entries, e)
+	}
+	return h, nil
+}
+
+// ParseHostsEntry returns the inline entry given by the option of a hosts plugin.  The address must be an IPv4 or
+// IPv6 address without a zone, followed by at least one name.
+func ParseHostsEntry(o *corefile.Option) (HostsEntry, error) {
+	if IsHostsOption(o.Name) {
+		return HostsEntry{}, fmt.Errorf("%w '%v': not an inline entry", ErrInvalidHostsEntry, o.Name)
+	}
+	addr, err := netip.ParseAddr(o.Name)
+	if err != nil {
+		return HostsEntry{}, fmt.Errorf("%w '%v': invalid address", ErrInvalidHostsEntry, o.Name)
+	}
+	if addr.Zone() != "" {
+		return HostsEntry{}, fmt.Errorf("%w '%v': address with a zone", ErrInvalidHostsEntry, o.Name)
+	}
+	if len(o.Args) == 0 {
+		return HostsEntry{}, fmt.Errorf("%w '%v': no names", ErrInvalidHostsEntry, o.Name)
+	}
+	return HostsEntry{Addr: addr, Names: o.Args}, nil
+}
+
+// HostsConflict is a name mapped to different addresses of the same family by inline entries of a hosts plugin.
+type HostsConflict struct {
+	Name  string       // the name, in lower case and fully qualified
+	Addrs []netip.Addr // the addresses, in order
+}
+
+// Duplicates returns the name/address pairs given more than once by the inline entries, in order, one entry per pair.
+// Names are compared in lower case and fully qualified, and IPv4-mapped IPv6 addresses as IPv4 addresses, as CoreDNS
+// does.
+func (h *Hosts) Duplicates() []HostsEntry {
+	type pair struct {
+		name string
+		addr netip.Addr
+	}
+	seen := map[pair]int{}
+	duplicates := []HostsEntry{}
+	for _, e := range h.Entries {
+		for _, name := range e.Names {
+			p := pair{name: hostsName(name), addr: e.Addr.Unmap()}
+			seen[p]++
+			if seen[p] == 2 {
+				duplicates = append(duplicates, HostsEntry{Addr: e.Addr, Names: []string{name}})
+			}
+		}
+	}
+	return duplicates
+}
+
+// Conflicts returns the names mapped to different addresses of the same family by the inline entries, in order.
+// CoreDNS answers with all of them, which is most often a stale entry left behind.  Names and addresses are compared
+// as by Duplicates.
+func (h *Hosts) Conflicts() []HostsConflict {
+	addrs := map[string][]netip.Addr{}
+	names := []string{}
+	for _, e := range h.Entries {
+		addr := e.Addr.Unmap()
+		for _, name := range e.Names {
+			n := hostsName(name)
+			if _, ok := addrs[n]; !ok {
+				names = append(names, n)
+			}
+			if !containsAddr(addrs[n], addr) {
+				addrs[n] = append(addrs[n], addr)
+			}
+		}
+	}
+	conflicts := []HostsConflict{}
+	for _, n := range names {
+		for _, is4 := range []bool{true, false} {
+			family := []netip.Addr{}
+			for _, a := range addrs[n] {
+				if a.Is4() == is4 {
+					family = append(family, a)
+				}
+			}
+			if len(family) > 1 {
+				conflicts = append(conflicts, HostsConflict{Name: n, Addrs: family})
+			}
+		}
+	}
+	return conflicts
+}
+
+func hostsName(name string) string {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+func containsAddr(addrs []netip.Addr, addr netip.Addr) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package migration
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestParseHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   string
+		expected *Hosts
+		err      bool
+	}{
+		{
+			name:     "default file",
+			plugin:   `hosts`,
+			expected: &Hosts{File: "/etc/hosts"},
+		},
+		{
+			name: "file, zones and entries",
+			plugin: `hosts /etc/coredns/hosts example.org example.com {
+        10.0.0.1 a.example.org b.example.org
+        reload 10s
+        fd00::1 a.example.org
+        fallthrough
+    }`,
+			expected: &Hosts{
+				File:  "/etc/coredns/hosts",
+				Zones: []string{"example.org", "example.com"},
+				Entries: []HostsEntry{
+					{Addr: netip.MustParseAddr("10.0.0.1"), Names: []string{"a.example.org", "b.example.org"}},
+					{Addr: netip.MustParseAddr("fd00::1"), Names: []string{"a.example.org"}},
+				},
+			},
+		},
+		{
+			name: "invalid address",
+			plugin: `hosts {
+        10.0.0.300 a.example.org
+    }`,
+			err: true,
+		},
+		{
+			name: "address with a zone",
+			plugin: `hosts {
+        fe80::1%eth0 a.example.org
+    }`,
+			err: true,
+		},
+		{
+			name: "no names",
+			plugin: `hosts {
+        10.0.0.1
+    }`,
+			err: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := parsePlugin(t, test.plugin)
+			h, err := ParseHosts(p)
+			if test.err {
+				if !errors.Is(err, ErrInvalidHostsEntry) {
+					t.Errorf("Expected error '%v', got '%v'", ErrInvalidHostsEntry, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.File != test.expected.File || !reflect.DeepEqual(h.Zones, test.expected.Zones) || !reflect.DeepEqual(h.Entries, test.expected.Entries) {
+				t.Errorf("Expected %+v, got %+v", test.expected, h)
+			}
+			if len(h.Entries)+len(h.Options) != len(p.Options) {
+				t.Errorf("Expected %v entries and options, got %v", len(p.Options), len(h.Entries)+len(h.Options))
+			}
+		})
+	}
+}
+
+func TestHostsDuplicatesAndConflicts(t *testing.T) {
+	h, err := ParseHosts(parsePlugin(t, `hosts {
+        10.0.0.1 a.example.org b.example.org
+        10.0.0.1 A.Example.org.
+        ::ffff:10.0.0.1 b.example.org
+        10.0.0.2 b.example.org
+        fd00::1 a.example.org
+        fd00::2 c.example.org
+    }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDuplicates := []HostsEntry{
+		{Addr: netip.MustParseAddr("10.0.0.1"), Names: []string{"A.Example.org."}},
+		{Addr: netip.MustParseAddr("::ffff:10.0.0.1"), Names: []string{"b.example.org"}},
+	}
+	if d := h.Duplicates(); !reflect.DeepEqual(d, expectedDuplicates) {
+		t.Errorf("Expected duplicates %v, got %v", expectedDuplicates, d)
+	}
+
+	expectedConflicts := []HostsConflict{
+		{Name: "b.example.org.", Addrs: []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")}},
+	}
+	if c := h.Conflicts(); !reflect.DeepEqual(c, expectedConflicts) {
+		t.Errorf("Expected conflicts %v, got %v", expectedConflicts, c)
+	}
+}
+
+func TestMigrateHosts(t *testing.T) {
+	startCorefile := `.:53 {
+    hosts /etc/coredns/hosts example.org {
+        10.0.0.2 b.example.org
+        reload 10s
+        10.0.0.1 a.example.org
+        fd00::1 a.example.org
+        1.2.3.4.5 c.example.org
+        fallthrough
+    }
+}
+`
+	result, err := Migrate("1.6.6", "1.11.1", startCorefile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != startCorefile {
+		t.Errorf("Expected inline entries to be kept in order:\n%v\nGot:\n%v", startCorefile, result)
+	}
+
+	notices, err := Unsupported("1.6.6", "1.6.7", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNotices(t, notices, []string{`Option "1.2.3.4.5" in plugin "hosts" is unsupported by this migration tool in 1.6.7.`})
+
+	_, err = MigrateWithOptions("1.6.6", "1.6.7", startCorefile, MigrateOptions{UnknownOptions: UnknownError})
+	var merr *MigrationError
+	if !errors.As(err, &merr) || !errors.Is(err, ErrUnsupported) || merr.Option != "1.2.3.4.5" {
+		t.Errorf("Expected an unsupported error for option '1.2.3.4.5', got '%v'", err)
+	}
+}
@@ -0,0 +1,158 @@
+package migration
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ImageRef is a parsed container image reference, e.g. "registry.k8s.io/coredns/coredns:v1.11.1@sha256:...".
+type ImageRef struct {
+	Registry   string // e.g. "registry.k8s.io", empty if the reference has no registry
+	Repository string // e.g. "coredns/coredns"
+	Tag        string // e.g. "v1.11.1"
+	Digest     string // hex encoded sha256 digest, without the "sha256:" prefix
+}
+
+// ParseImageRef parses a container image reference.  Besides full references, a bare digest (with or without the
+// "sha256:" prefix) is accepted.
+func ParseImageRef(ref string) (ImageRef, error) {
+	var r ImageRef
+	s := strings.TrimSpace(ref)
+	if s == "" {
+		return r, fmt.Errorf("invalid image reference '%v'", ref)
+	}
+	if isDigest(s) {
+		r.Digest = normalizeDigest(s)
+		return r, nil
+	}
+	if i := strings.Index(s, "@"); i >= 0 {
+		if !isDigest(s[i+1:]) || !strings.HasPrefix(s[i+1:], "sha256:") {
+			return r, fmt.Errorf("invalid image reference '%v': invalid digest", ref)
+		}
+		r.Digest = normalizeDigest(s[i+1:])
+		s = s[:i]
+	}
+	// a tag follows the last colon, unless that colon belongs to a registry port
+	if i := strings.LastIndex(s, ":"); i >= 0 && !strings.Contains(s[i+1:], "/") {
+		r.Tag = s[i+1:]
+		s = s[:i]
+		if r.Tag == "" {
+			return r, fmt.Errorf("invalid image reference '%v': empty tag", ref)
+		}
+	}
+	// the first path component is a registry if it looks like a host name
+	if i := strings.Index(s, "/"); i >= 0 {
+		host := s[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			r.Registry = host
+			s = s[i+1:]
+		}
+	}
+	if s == "" {
+		return r, fmt.Errorf("invalid image reference '%v': empty repository", ref)
+	}
+	r.Repository = s
+	return r, nil
+}
+
+// String returns the image reference in its canonical form.
+func (r ImageRef) String() string {
+	s := r.Repository
+	if r.Registry != "" {
+		s = r.Registry + "/" + s
+	}
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@sha256:" + r.Digest
+	}
+	return s
+}
+
+// IsCoreDNS returns true if the image reference names a CoreDNS repository, i.e. one named "coredns" such as
+// "coredns/coredns", "k8s.gcr.io/coredns" or a mirror of those, or if it is a bare digest.
+func (r ImageRef) IsCoreDNS() bool {
+	if r.Repository == "" {
+		return true
+	}
+	return path.Base(r.Repository) == "coredns"
+}
+
+// isDigest returns true if s is a sha256 digest, with or without the "sha256:" prefix.
+func isDigest(s string) bool {
+	s = strings.TrimPrefix(s, "sha256:")
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeDigest returns the digest in the form used by the release catalog.
+func normalizeDigest(s string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "sha256:"))
+}
+
+// ImageMismatchError is returned when the tag and the digest of an image reference identify different releases.
+type ImageMismatchError struct {
+	Ref           string
+	TagVersion    string // the release identified by the tag
+	DigestVersion string // the release identified by the digest
+}
+
+func (e *ImageMismatchError) Error() string {
+	return fmt.Sprintf("%v: tag is CoreDNS %v, but the digest belongs to CoreDNS %v", e.Ref, e.TagVersion, e.DigestVersion)
+}
+
+// Is reports whether target is ErrImageMismatch.
+func (e *ImageMismatchError) Is(target error) bool { return target == ErrImageMismatch }
+
+// VersionFromImage returns the version string matching the image reference.  See Migrator.VersionFromImage.
+func VersionFromImage(ref string) (string, error) {
+	return defaultMigrator.VersionFromImage(ref)
+}
+
+// VersionFromImage returns the version string in the Migrator's catalog matching the image reference, e.g.
+// "registry.k8s.io/coredns/coredns:v1.11.1", "k8s.gcr.io/coredns:1.6.7" or "coredns/coredns@sha256:...".  The release
+// is identified by the tag, the digest, or both.  The digest may be the digest of the manifest list, or of any of
+// the per-platform images.  If the tag and digest identify different releases, an *ImageMismatchError is returned.
+// A tag that does not resolve fails even if the digest does, except for the floating "latest" tag, which is left to
+// the digest.  References to repositories other than CoreDNS's (see ImageRef.IsCoreDNS) fail with ErrNotCoreDNSImage.
+func (m *Migrator) VersionFromImage(ref string) (string, error) {
+	r, err := ParseImageRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if !r.IsCoreDNS() {
+		return "", fmt.Errorf("%w: '%v'", ErrNotCoreDNSImage, ref)
+	}
+
+	var tagVersion string
+	if r.Tag != "" && !(r.Tag == "latest" && r.Digest != "") {
+		tagVersion, err = m.ResolveVersion(r.Tag)
+		if err != nil {
+			return "", err
+		}
+	}
+	if r.Digest == "" {
+		if tagVersion == "" {
+			return "", fmt.Errorf("%w: image reference '%v' has no tag or digest", ErrUnknownVersion, ref)
+		}
+		return tagVersion, nil
+	}
+
+	digestVersion, err := m.VersionFromSHA(r.Digest)
+	if err != nil {
+		return "", err
+	}
+	if tagVersion != "" && tagVersion != digestVersion {
+		return "", &ImageMismatchError{Ref: ref, TagVersion: tagVersion, DigestVersion: digestVersion}
+	}
+	return digestVersion, nil
+}
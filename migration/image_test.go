@@ -0,0 +1,130 @@
+package migration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	sha1_11_1 = "1eeb4c7316bacb1d4c8ead65571cd92dd21e27359f0d4917f1a5822a73b75db1"
+	sha1_10_1 = "a0ead06651cf580044aeb0a0feba63591858fb2e43ade8c9dea45a6a89ae7e5e"
+)
+
+func TestParseImageRef(t *testing.T) {
+	testCases := []struct {
+		ref       string
+		expected  ImageRef
+		shouldErr bool
+	}{
+		{
+			ref:      "registry.k8s.io/coredns/coredns:v1.11.1@sha256:" + sha1_11_1,
+			expected: ImageRef{Registry: "registry.k8s.io", Repository: "coredns/coredns", Tag: "v1.11.1", Digest: sha1_11_1},
+		},
+		{
+			ref:      "k8s.gcr.io/coredns:1.6.7",
+			expected: ImageRef{Registry: "k8s.gcr.io", Repository: "coredns", Tag: "1.6.7"},
+		},
+		{
+			ref:      "coredns/coredns:1.8.0",
+			expected: ImageRef{Repository: "coredns/coredns", Tag: "1.8.0"},
+		},
+		{
+			ref:      "localhost:5000/coredns@sha256:" + sha1_10_1,
+			expected: ImageRef{Registry: "localhost:5000", Repository: "coredns", Digest: sha1_10_1},
+		},
+		{
+			ref:      "sha256:" + sha1_10_1,
+			expected: ImageRef{Digest: sha1_10_1},
+		},
+		{
+			ref:      sha1_10_1,
+			expected: ImageRef{Digest: sha1_10_1},
+		},
+		{ref: "", shouldErr: true},
+		{ref: "coredns/coredns:", shouldErr: true},
+		{ref: "coredns/coredns@sha256:1234", shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		r, err := ParseImageRef(tc.ref)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("expected '%v' to error", tc.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to parse, got '%v'", tc.ref, err)
+			continue
+		}
+		if r != tc.expected {
+			t.Errorf("expected '%v' to parse as %+v, got %+v", tc.ref, tc.expected, r)
+		}
+	}
+}
+
+func TestVersionFromImage(t *testing.T) {
+	catalog := DefaultCatalog()
+	r := catalog["1.11.1"]
+	r.platformDigests = map[string]string{"linux/arm64": "0000000000000000000000000000000000000000000000000000000000000001"}
+	catalog["1.11.1"] = r
+	m := NewMigrator(catalog)
+
+	testCases := []struct {
+		ref      string
+		expected string
+		err      error
+	}{
+		{ref: "registry.k8s.io/coredns/coredns:v1.11.1", expected: "1.11.1"},
+		{ref: "registry.k8s.io/coredns/coredns:v1.11.1@sha256:" + sha1_11_1, expected: "1.11.1"},
+		{ref: "k8s.gcr.io/coredns:1.6.7", expected: "1.6.7"},
+		{ref: "coredns/coredns@sha256:" + sha1_10_1, expected: "1.10.1"},
+		{ref: "coredns/coredns:latest@sha256:" + sha1_10_1, expected: "1.10.1"},
+		{ref: "sha256:" + sha1_11_1, expected: "1.11.1"},
+		{ref: "sha256:0000000000000000000000000000000000000000000000000000000000000001", expected: "1.11.1"},
+		{ref: "coredns/coredns:1.11.1@sha256:0000000000000000000000000000000000000000000000000000000000000001", expected: "1.11.1"},
+		{ref: "mirror.example.org:5000/k8s/coredns:v1.11.1", expected: "1.11.1"},
+		{ref: "foo/bar:1.11.1", err: ErrNotCoreDNSImage},
+		{ref: "registry.k8s.io/coredns/coredns-extra:v1.11.1", err: ErrNotCoreDNSImage},
+		{ref: "registry.k8s.io/coredns/coredns:v1.11.1@sha256:" + sha1_10_1, err: ErrImageMismatch},
+		{ref: "registry.k8s.io/coredns/coredns:v1.99.0", err: ErrUnknownVersion},
+		{ref: "coredns/coredns:bogus@sha256:" + sha1_10_1, err: ErrUnknownVersion},
+		{ref: "coredns/coredns:latest", err: ErrUnknownVersion},
+		{ref: "registry.k8s.io/coredns/coredns", err: ErrUnknownVersion},
+		{ref: "sha256:0000000000000000000000000000000000000000000000000000000000000002", err: ErrUnknownSHA},
+	}
+
+	for _, tc := range testCases {
+		v, err := m.VersionFromImage(tc.ref)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected '%v' to fail with '%v', got '%v' (%v)", tc.ref, tc.err, v, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to resolve, got '%v'", tc.ref, err)
+			continue
+		}
+		if v != tc.expected {
+			t.Errorf("expected '%v' to resolve to '%v', got '%v'", tc.ref, tc.expected, v)
+		}
+	}
+
+	var mErr *ImageMismatchError
+	_, err := m.VersionFromImage("coredns/coredns:1.11.1@sha256:" + sha1_10_1)
+	if !errors.As(err, &mErr) || mErr.TagVersion != "1.11.1" || mErr.DigestVersion != "1.10.1" {
+		t.Errorf("expected a mismatch between 1.11.1 and 1.10.1, got '%v'", err)
+	}
+
+	if !Released("sha256:"+sha1_11_1) || !m.Released(strings.ToUpper(sha1_11_1)) {
+		t.Errorf("expected prefixed and upper case digests to be released")
+	}
+	if !m.Released("0000000000000000000000000000000000000000000000000000000000000001") {
+		t.Errorf("expected per-platform digests to be released")
+	}
+	if Released("0000000000000000000000000000000000000000000000000000000000000001") {
+		t.Errorf("expected per-platform digests of a catalog copy not to leak into the default catalog")
+	}
+}
@@ -0,0 +1,162 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// kubeDNSClusterDomain is the default cluster domain of kube-dns, used when KubeDNSOptions.ClusterDomain is not set.
+const kubeDNSClusterDomain = "cluster.local"
+
+// KubeDNSOptions are options for converting a kube-dns ConfigMap to a Corefile.
+type KubeDNSOptions struct {
+	// ClusterDomain is the cluster domain served by the kubernetes plugin, "cluster.local" if empty.  The ConfigMap
+	// does not hold it: kube-dns takes it from its --domain flag, see KubeDNSClusterDomain.
+	ClusterDomain string
+}
+
+// KubeDNSClusterDomain returns the cluster domain set by the --domain flag in the arguments of a kube-dns container,
+// without its trailing dot, or "" if the flag is not set.
+func KubeDNSClusterDomain(args []string) string {
+	domain := ""
+	for i, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--domain="):
+			domain = strings.TrimPrefix(arg, "--domain=")
+		case arg == "--domain" && i+1 < len(args):
+			domain = args[i+1]
+		}
+	}
+	return strings.TrimSuffix(domain, ".")
+}
+
+// FromKubeDNS returns a Corefile for toCoreDNSVersion equivalent to the data of a kube-dns ConfigMap, for the cluster
+// domain "cluster.local".  See Migrator.FromKubeDNSWithOptions.
+func FromKubeDNS(configMap map[string]string, toCoreDNSVersion string) (string, error) {
+	return defaultMigrator.FromKubeDNS(configMap, toCoreDNSVersion)
+}
+
+// FromKubeDNSWithOptions returns a Corefile for toCoreDNSVersion equivalent to the data of a kube-dns ConfigMap.  See
+// Migrator.FromKubeDNSWithOptions.
+func FromKubeDNSWithOptions(configMap map[string]string, toCoreDNSVersion string, opts KubeDNSOptions) (string, error) {
+	return defaultMigrator.FromKubeDNSWithOptions(configMap, toCoreDNSVersion, opts)
+}
+
+// FromKubeDNS returns a Corefile for toCoreDNSVersion equivalent to the data of a kube-dns ConfigMap, for the cluster
+// domain "cluster.local".  See Migrator.FromKubeDNSWithOptions.
+func (m *Migrator) FromKubeDNS(configMap map[string]string, toCoreDNSVersion string) (string, error) {
+	return m.FromKubeDNSWithOptions(configMap, toCoreDNSVersion, KubeDNSOptions{})
+}
+
+// FromKubeDNSWithOptions returns a Corefile for toCoreDNSVersion equivalent to the data of a kube-dns ConfigMap, using
+// the Migrator's catalog.  The "stubDomains" entry, a JSON map of domains to name servers, becomes one server block per
+// stub domain forwarding to its name servers.  The "upstreamNameservers" entry, a JSON list of name servers, replaces
+// /etc/resolv.conf as the upstream of the default server block.  The default server block is the default Corefile of
+// the latest release up to toCoreDNSVersion shipping one, for the cluster domain opts.ClusterDomain, migrated to
+// toCoreDNSVersion.
+func (m *Migrator) FromKubeDNSWithOptions(configMap map[string]string, toCoreDNSVersion string, opts KubeDNSOptions) (string, error) {
+	clusterDomain := strings.TrimSuffix(opts.ClusterDomain, ".")
+	if clusterDomain == "" {
+		clusterDomain = kubeDNSClusterDomain
+	}
+	if strings.ContainsAny(clusterDomain, " \t{}#;\"") {
+		return "", fmt.Errorf("%w: invalid cluster domain %q", ErrInvalidKubeDNSConfig, opts.ClusterDomain)
+	}
+	toCoreDNSVersion, err := m.ResolveVersion(toCoreDNSVersion)
+	if err != nil {
+		return "", err
+	}
+	stubDomains := map[string][]string{}
+	if s := strings.TrimSpace(configMap["stubDomains"]); s != "" {
+		if err := json.Unmarshal([]byte(s), &stubDomains); err != nil {
+			return "", fmt.Errorf("%w: stubDomains: %v", ErrInvalidKubeDNSConfig, err)
+		}
+	}
+	upstreams := []string{}
+	if s := strings.TrimSpace(configMap["upstreamNameservers"]); s != "" {
+		if err := json.Unmarshal([]byte(s), &upstreams); err != nil {
+			return "", fmt.Errorf("%w: upstreamNameservers: %v", ErrInvalidKubeDNSConfig, err)
+		}
+	}
+	if s := strings.TrimSpace(configMap["federations"]); s != "" && s != "{}" {
+		return "", fmt.Errorf("kube-dns federations are %w", ErrUnsupported)
+	}
+	if err := validKubeDNSNameservers("upstreamNameservers", upstreams); err != nil {
+		return "", err
+	}
+	for domain, nameservers := range stubDomains {
+		if len(nameservers) == 0 {
+			return "", fmt.Errorf("%w: stub domain %q has no name servers", ErrInvalidKubeDNSConfig, domain)
+		}
+		if err := validKubeDNSNameservers("stub domain "+domain, nameservers); err != nil {
+			return "", err
+		}
+	}
+
+	// start from the latest default Corefile up to the target version
+	base := toCoreDNSVersion
+	for m.catalog[base].defaultConf == "" {
+		base = m.catalog[base].priorVersion
+		if base == "" {
+			return "", fmt.Errorf("%w: no default Corefile up to CoreDNS %v", ErrUnknownVersion, toCoreDNSVersion)
+		}
+	}
+	cf, err := corefile.New(m.catalog[base].defaultConf)
+	if err != nil {
+		return "", err
+	}
+	upstream := "forward"
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			switch {
+			case p.Name == "kubernetes" && len(p.Args) == 2 && p.Args[0] == "*" && p.Args[1] == "***":
+				p.Args = []string{clusterDomain, "in-addr.arpa", "ip6.arpa"}
+			case (p.Name == "forward" || p.Name == "proxy") && len(p.Args) == 2 && p.Args[0] == "." && p.Args[1] == "*":
+				upstream = p.Name
+				if len(upstreams) > 0 {
+					p.Args = append([]string{"."}, upstreams...)
+				} else {
+					p.Args = []string{".", "/etc/resolv.conf"}
+				}
+			}
+		}
+	}
+	domains := []string{}
+	for domain := range stubDomains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		s := &corefile.Server{DomPorts: []string{domain + ":53"}}
+		s.Plugins = append(s.Plugins, &corefile.Plugin{Name: "errors"}, &corefile.Plugin{Name: "cache", Args: []string{"30"}})
+		if _, ok := m.catalog[base].plugins["loop"]; ok {
+			s.Plugins = append(s.Plugins, &corefile.Plugin{Name: "loop"})
+		}
+		s.Plugins = append(s.Plugins, &corefile.Plugin{Name: upstream, Args: append([]string{"."}, stubDomains[domain]...)})
+		cf.Servers = append(cf.Servers, s)
+	}
+
+	if base == toCoreDNSVersion {
+		return cf.ToString(), nil
+	}
+	return m.MigrateWithOptions(base, toCoreDNSVersion, cf.ToString(), MigrateOptions{Deprecations: true})
+}
+
+// validKubeDNSNameservers returns an error if any of the name servers is not an IP address, with an optional port.
+func validKubeDNSNameservers(field string, nameservers []string) error {
+	for _, ns := range nameservers {
+		host := ns
+		if h, _, err := net.SplitHostPort(ns); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("%w: %v: invalid name server %q", ErrInvalidKubeDNSConfig, field, ns)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromKubeDNS(t *testing.T) {
+	configMap := map[string]string{
+		"stubDomains":         `{"acme.local": ["1.2.3.4"], "beta.local": ["5.6.7.8", "5.6.7.9:5353"]}`,
+		"upstreamNameservers": `["8.8.8.8", "8.8.4.4"]`,
+	}
+	tests := []struct {
+		name      string
+		configMap map[string]string
+		version   string
+		expected  string
+		err       error
+	}{
+		{
+			name:      "default Corefile of the version",
+			configMap: configMap,
+			version:   "1.6.7",
+			expected: `.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    ready
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . 8.8.8.8 8.8.4.4
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+acme.local:53 {
+    errors
+    cache 30
+    loop
+    forward . 1.2.3.4
+}
+
+beta.local:53 {
+    errors
+    cache 30
+    loop
+    forward . 5.6.7.8 5.6.7.9:5353
+}
+`,
+		},
+		{
+			name:      "migrated to the version",
+			configMap: map[string]string{"stubDomains": `{"acme.local": ["1.2.3.4"]}`},
+			version:   "v1.8.6",
+			expected: `.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    ready
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+acme.local:53 {
+    errors
+    cache 30
+    loop
+    forward . 1.2.3.4
+}
+`,
+		},
+		{
+			name:      "proxy versions",
+			configMap: map[string]string{"stubDomains": `{"acme.local": ["1.2.3.4"]}`},
+			version:   "1.2.2",
+			expected: `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+
+acme.local:53 {
+    errors
+    cache 30
+    loop
+    proxy . 1.2.3.4
+}
+`,
+		},
+		{
+			name:      "invalid JSON",
+			configMap: map[string]string{"stubDomains": `{"acme.local": "1.2.3.4"}`},
+			version:   "1.6.7",
+			err:       ErrInvalidKubeDNSConfig,
+		},
+		{
+			name:      "invalid name server",
+			configMap: map[string]string{"upstreamNameservers": `["dns.google"]`},
+			version:   "1.6.7",
+			err:       ErrInvalidKubeDNSConfig,
+		},
+		{
+			name:      "federations",
+			configMap: map[string]string{"federations": `{"myfederation": "example.com"}`},
+			version:   "1.6.7",
+			err:       ErrUnsupported,
+		},
+		{
+			name:    "unknown version",
+			version: "0.1.0",
+			err:     ErrUnknownVersion,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FromKubeDNS(test.configMap, test.version)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error '%v', got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromKubeDNSClusterDomain(t *testing.T) {
+	configMap := map[string]string{"upstreamNameservers": `["8.8.8.8"]`}
+	got, err := FromKubeDNSWithOptions(configMap, "1.6.7", KubeDNSOptions{ClusterDomain: "corp.example."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    ready
+    kubernetes corp.example in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . 8.8.8.8
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+	if got != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, got)
+	}
+
+	if _, err := FromKubeDNSWithOptions(configMap, "1.6.7", KubeDNSOptions{ClusterDomain: "corp example"}); !errors.Is(err, ErrInvalidKubeDNSConfig) {
+		t.Errorf("expected error '%v', got '%v'", ErrInvalidKubeDNSConfig, err)
+	}
+}
+
+func TestKubeDNSClusterDomain(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"--domain=cluster.local.", "--dns-port=10053"}, expected: "cluster.local"},
+		{args: []string{"--dns-port=10053", "--domain", "corp.example"}, expected: "corp.example"},
+		{args: []string{"--dns-port=10053"}, expected: ""},
+		{args: nil, expected: ""},
+	}
+	for _, test := range tests {
+		if got := KubeDNSClusterDomain(test.args); got != test.expected {
+			t.Errorf("KubeDNSClusterDomain(%q): expected %q, got %q", test.args, test.expected, got)
+		}
+	}
+}
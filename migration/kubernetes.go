@@ -0,0 +1,92 @@
+package migration
+
+import (
+	"fmt"
+)
+
+// CoreDNSVersionForKubernetes returns the CoreDNS version deployed by default with the given Kubernetes version.
+// See Migrator.CoreDNSVersionForKubernetes.
+func CoreDNSVersionForKubernetes(k8sVersion string) (string, error) {
+	return defaultMigrator.CoreDNSVersionForKubernetes(k8sVersion)
+}
+
+// CoreDNSVersionForKubernetes returns the CoreDNS version in the Migrator's catalog deployed by default with the given
+// Kubernetes version.  Patch releases and suffixes of the Kubernetes version are ignored (e.g. "v1.29.3-eks-1" is read
+// as "1.29").  A Kubernetes release missing from the catalog, but between releases that are in it, gets the CoreDNS
+// version of the nearest prior Kubernetes release.
+func (m *Migrator) CoreDNSVersionForKubernetes(k8sVersion string) (string, error) {
+	k8s, err := parseK8sVersion(k8sVersion)
+	if err != nil {
+		return "", err
+	}
+	releases := m.KubernetesReleases()
+	if len(releases) == 0 {
+		return "", fmt.Errorf("%w: no Kubernetes releases in the catalog", ErrUnknownKubernetesVersion)
+	}
+	first, _ := ParseVersion(releases[0])
+	last, _ := ParseVersion(releases[len(releases)-1])
+	if k8s.Less(first) || last.Less(k8s) {
+		return "", fmt.Errorf("%w '%v': not in the range %v to %v", ErrUnknownKubernetesVersion, k8sVersion,
+			releases[0], releases[len(releases)-1])
+	}
+
+	// find the newest Kubernetes release in the catalog not newer than k8s
+	match := ""
+	for _, r := range releases {
+		rv, _ := ParseVersion(r)
+		if k8s.Less(rv) {
+			break
+		}
+		match = r
+	}
+	for vStr, v := range m.catalog {
+		for _, r := range v.k8sReleases {
+			if r == match {
+				return vStr, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w '%v'", ErrUnknownKubernetesVersion, k8sVersion)
+}
+
+// KubernetesReleasesFor returns the Kubernetes releases that deploy the given CoreDNS version by default.
+func KubernetesReleasesFor(corednsVersion string) ([]string, error) {
+	return defaultMigrator.KubernetesReleasesFor(corednsVersion)
+}
+
+// KubernetesReleasesFor returns the Kubernetes releases in the Migrator's catalog that deploy the given CoreDNS version
+// by default, sorted in ascending order.  The list is empty if the CoreDNS version is valid but not deployed by default
+// by any Kubernetes release.
+func (m *Migrator) KubernetesReleasesFor(corednsVersion string) ([]string, error) {
+	v, err := m.ResolveVersion(corednsVersion)
+	if err != nil {
+		return nil, err
+	}
+	releases := append([]string{}, m.catalog[v].k8sReleases...)
+	sortVersions(releases)
+	return releases, nil
+}
+
+// KubernetesReleases returns all Kubernetes releases in the default catalog.
+func KubernetesReleases() []string {
+	return defaultMigrator.KubernetesReleases()
+}
+
+// KubernetesReleases returns all Kubernetes releases in the Migrator's catalog, sorted in ascending order.
+func (m *Migrator) KubernetesReleases() []string {
+	var releases []string
+	for _, v := range m.catalog {
+		releases = append(releases, v.k8sReleases...)
+	}
+	sortVersions(releases)
+	return releases
+}
+
+// parseK8sVersion parses a Kubernetes version, dropping its patch release and suffix.
+func parseK8sVersion(k8sVersion string) (Version, error) {
+	v, err := ParseVersion(k8sVersion)
+	if err != nil {
+		return v, fmt.Errorf("%w '%v': invalid version format", ErrUnknownKubernetesVersion, k8sVersion)
+	}
+	return Version{Major: v.Major, Minor: v.Minor}, nil
+}
@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCoreDNSVersionForKubernetes(t *testing.T) {
+	testCases := []struct {
+		k8sVersion string
+		expected   string
+	}{
+		{k8sVersion: "1.11", expected: "1.1.3"},
+		{k8sVersion: "1.14", expected: "1.3.1"},
+		{k8sVersion: "1.22", expected: "1.8.4"},
+		{k8sVersion: "1.24", expected: "1.8.6"},
+		{k8sVersion: "v1.29.3", expected: "1.11.1"},
+		{k8sVersion: "1.30.2-eks-1552ad0", expected: "1.11.1"},
+		{k8sVersion: "1.31.1+k3s1", expected: "1.11.3"},
+		{k8sVersion: "1.34", expected: "1.12.1"},
+		{k8sVersion: "1.10"},
+		{k8sVersion: "1.99"},
+		{k8sVersion: "banana"},
+	}
+
+	for _, tc := range testCases {
+		v, err := CoreDNSVersionForKubernetes(tc.k8sVersion)
+		if tc.expected == "" {
+			if !errors.Is(err, ErrUnknownKubernetesVersion) {
+				t.Errorf("expected '%v' to fail with ErrUnknownKubernetesVersion, got '%v' (%v)", tc.k8sVersion, v, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to resolve, got '%v'", tc.k8sVersion, err)
+			continue
+		}
+		if v != tc.expected {
+			t.Errorf("expected Kubernetes '%v' to deploy CoreDNS '%v', got '%v'", tc.k8sVersion, tc.expected, v)
+		}
+	}
+
+	// Kubernetes releases between defaults get the CoreDNS version of the nearest prior release
+	catalog := DefaultCatalog()
+	r := catalog["1.8.6"]
+	r.k8sReleases = []string{"1.23"}
+	catalog["1.8.6"] = r
+	v, err := NewMigrator(catalog).CoreDNSVersionForKubernetes("1.24")
+	if err != nil || v != "1.8.6" {
+		t.Errorf("expected Kubernetes '1.24' to deploy CoreDNS '1.8.6', got '%v' (%v)", v, err)
+	}
+}
+
+func TestKubernetesReleasesFor(t *testing.T) {
+	testCases := []struct {
+		corednsVersion string
+		expected       []string
+		shouldErr      bool
+	}{
+		{corednsVersion: "1.3.1", expected: []string{"1.14", "1.15"}},
+		{corednsVersion: "v1.11.3", expected: []string{"1.31", "1.32"}},
+		{corednsVersion: "1.11.4", expected: []string{}},
+		{corednsVersion: "1.99.0", shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		releases, err := KubernetesReleasesFor(tc.corednsVersion)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("expected '%v' to error", tc.corednsVersion)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to not error, got '%v'", tc.corednsVersion, err)
+			continue
+		}
+		if !reflect.DeepEqual(releases, tc.expected) {
+			t.Errorf("expected CoreDNS '%v' to be deployed by %v, got %v", tc.corednsVersion, tc.expected, releases)
+		}
+	}
+}
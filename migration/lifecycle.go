@@ -0,0 +1,103 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Timeline is the lifecycle of a single plugin/option, or rewrite rule form, in a server block across a migration,
+// e.g. "deprecated in 1.4.0, ignored in 1.5.0, removed in 1.7.0".
+type Timeline struct {
+	Plugin     string
+	Option     string
+	Rule       string // the form of a rewrite rule, e.g. "name regex", see RewriteRule
+	Server     string // the server block the plugin/option is in, e.g. ".:53"
+	ReplacedBy string
+	Additional string
+	Events     []TimelineEvent // changes of severity, in version order
+}
+
+// TimelineEvent is a change of severity of a plugin/option in a Timeline.
+type TimelineEvent struct {
+	Severity Severity
+	Version  string // the first version with this severity
+}
+
+// Lifecycle returns the deprecation notices affecting the given Corefile (see Deprecated), grouped into one Timeline
+// per plugin/option and server block.
+func Lifecycle(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Timeline, error) {
+	return defaultMigrator.Lifecycle(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+}
+
+// Lifecycle returns the deprecation notices affecting the given Corefile, grouped into one Timeline per plugin/option
+// and server block, using the Migrator's catalog.
+func (m *Migrator) Lifecycle(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Timeline, error) {
+	notices, err := m.Deprecated(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+	if err != nil {
+		return nil, err
+	}
+	return GroupNotices(notices), nil
+}
+
+// GroupNotices groups notices into one Timeline per plugin/option or rule form, and server block.  Notices must be in version
+// order, as returned by Deprecated and Unsupported.  Timelines are returned in order of their first notice.
+func GroupNotices(notices []Notice) []Timeline {
+	type key struct{ plugin, option, rule, server string }
+	timelines := []Timeline{}
+	index := map[key]int{}
+	for _, n := range notices {
+		k := key{n.Plugin, n.Option, n.Rule, n.Server}
+		i, ok := index[k]
+		if !ok {
+			i = len(timelines)
+			index[k] = i
+			timelines = append(timelines, Timeline{Plugin: n.Plugin, Option: n.Option, Rule: n.Rule, Server: n.Server})
+		}
+		t := &timelines[i]
+		if n.ReplacedBy != "" {
+			t.ReplacedBy = n.ReplacedBy
+		}
+		if n.Additional != "" {
+			t.Additional = n.Additional
+		}
+		if len(t.Events) > 0 && t.Events[len(t.Events)-1].Severity == n.Severity {
+			continue
+		}
+		t.Events = append(t.Events, TimelineEvent{Severity: n.Severity, Version: n.Version})
+	}
+	return timelines
+}
+
+func (t *Timeline) ToString() string {
+	s := ""
+	switch {
+	case t.Rule != "":
+		s += fmt.Sprintf(`Rule "%v" in plugin "%v" `, t.Rule, t.Plugin)
+	case t.Option != "":
+		s += fmt.Sprintf(`Option "%v" in plugin "%v" `, t.Option, t.Plugin)
+	default:
+		s += fmt.Sprintf(`Plugin "%v" `, t.Plugin)
+	}
+	if t.Server != "" {
+		s += fmt.Sprintf(`in server block "%v" `, t.Server)
+	}
+	events := make([]string, 0, len(t.Events))
+	for _, e := range t.Events {
+		switch e.Severity {
+		case SevUnsupported:
+			events = append(events, "unsupported by this migration tool in "+e.Version)
+		case SevNewDefault:
+			events = append(events, "added as a default in "+e.Version)
+		default:
+			events = append(events, string(e.Severity)+" in "+e.Version)
+		}
+	}
+	s += "is " + strings.Join(events, ", ") + "."
+	if t.ReplacedBy != "" {
+		s += fmt.Sprintf(` It is replaced by "%v".`, t.ReplacedBy)
+	}
+	if t.Additional != "" {
+		s += " " + t.Additional
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package migration
+
+import (
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	startCorefile := `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+        resyncperiod
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    reload
+    loop
+    loadbalance
+}
+example.org:53 {
+    proxy . 10.0.0.1
+}
+`
+
+	expected := []string{
+		`Plugin "loop" in server block "example.org:53" is added as a default in 1.2.1.`,
+		`Option "upstream" in plugin "kubernetes" in server block ".:53" is deprecated in 1.4.0, ignored in 1.5.0, removed in 1.7.0.`,
+		`Plugin "proxy" in server block ".:53" is deprecated in 1.4.0, removed in 1.5.0. It is replaced by "forward".`,
+		`Plugin "proxy" in server block "example.org:53" is deprecated in 1.4.0, removed in 1.5.0. It is replaced by "forward".`,
+		`Option "resyncperiod" in plugin "kubernetes" in server block ".:53" is deprecated in 1.5.0, ignored in 1.6.0, removed in 1.7.0.`,
+		`Plugin "ready" in server block ".:53" is added as a default in 1.5.0.`,
+		`Plugin "ready" in server block "example.org:53" is added as a default in 1.5.0.`,
+		`Option "lameduck" in plugin "health" in server block ".:53" is added as a default in 1.6.5.`,
+	}
+
+	result, err := Lifecycle("1.1.3", "1.7.0", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("expected to find %v timelines; got %v", len(expected), len(result))
+	}
+
+	for i, tl := range expected {
+		if result[i].ToString() != tl {
+			t.Errorf("expected to get '%v'; got '%v'", tl, result[i].ToString())
+		}
+	}
+}
+
+func TestLifecycleRules(t *testing.T) {
+	catalog := rewriteCatalog()
+	rw := catalog["1.11.1"].plugins["rewrite"]
+	rw.rules["ttl"] = rule{status: SevDeprecated, replacedBy: "answer auto"}
+	rw.rules["edns0"] = rule{status: SevDeprecated, replacedBy: "name"}
+	catalog["1.11.1"].plugins["rewrite"] = rw
+
+	startCorefile := `.:53 {
+    rewrite ttl exact example.org 30
+    rewrite edns0 local set 0xffee abcd
+    forward . /etc/resolv.conf
+}
+`
+	expected := []string{
+		`Rule "ttl" in plugin "rewrite" in server block ".:53" is deprecated in 1.11.1. It is replaced by "answer auto".`,
+		`Rule "edns0" in plugin "rewrite" in server block ".:53" is deprecated in 1.11.1. It is replaced by "name".`,
+	}
+
+	result, err := NewMigrator(catalog).Lifecycle("1.11.0", "1.11.1", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("expected to find %v timelines; got %v", len(expected), len(result))
+	}
+	for i, tl := range expected {
+		if result[i].ToString() != tl {
+			t.Errorf("expected to get '%v'; got '%v'", tl, result[i].ToString())
+		}
+	}
+}
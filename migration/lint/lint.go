@@ -0,0 +1,128 @@
+// Package lint checks Corefiles for common misconfigurations, i.e. configurations that are valid and migrate fine,
+// but do not behave as intended.
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// Severity is the severity of a Finding.
+type Severity string
+
+const (
+	SevInfo    Severity = "info"    // a suggestion
+	SevWarning Severity = "warning" // likely a misconfiguration
+	SevError   Severity = "error"   // CoreDNS will not start, or will not work as intended
+)
+
+// severities lists the finding severities in increasing order.
+var severities = []Severity{SevInfo, SevWarning, SevError}
+
+// ParseSeverity returns the Severity named s.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range severities {
+		if string(sev) == s {
+			return sev, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity '%v'", s)
+}
+
+// AtLeast returns true if s is o or higher.
+func (s Severity) AtLeast(o Severity) bool {
+	return s.rank() >= o.rank()
+}
+
+func (s Severity) rank() int {
+	for i, sev := range severities {
+		if sev == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Finding is a problem found in a Corefile by a Rule.
+type Finding struct {
+	Rule     string // the name of the rule raising the finding
+	Severity Severity
+	Server   string // the server block, e.g. ".:53"
+	Plugin   string // the plugin name, if the finding is about a plugin
+	Option   string // the option name, if the finding is about an option
+	Message  string
+}
+
+func (f *Finding) ToString() string {
+	loc := []string{fmt.Sprintf(`server block "%v"`, f.Server)}
+	if f.Plugin != "" {
+		loc = append(loc, fmt.Sprintf(`plugin "%v"`, f.Plugin))
+	}
+	if f.Option != "" {
+		loc = append(loc, fmt.Sprintf(`option "%v"`, f.Option))
+	}
+	return fmt.Sprintf("[%v] %v: %v: %v", f.Severity, f.Rule, strings.Join(loc, ", "), f.Message)
+}
+
+// Rule checks a Corefile for a single kind of misconfiguration.
+type Rule interface {
+	// Name returns the name of the rule, e.g. "forward-without-loop".
+	Name() string
+	// Check returns the findings of the rule in the Corefile.
+	Check(cf *corefile.Corefile) []Finding
+}
+
+// NewRule returns a Rule named name, checking Corefiles with check.
+func NewRule(name string, check func(cf *corefile.Corefile) []Finding) Rule {
+	return &funcRule{name: name, check: check}
+}
+
+type funcRule struct {
+	name  string
+	check func(cf *corefile.Corefile) []Finding
+}
+
+func (r *funcRule) Name() string { return r.name }
+
+func (r *funcRule) Check(cf *corefile.Corefile) []Finding {
+	findings := r.check(cf)
+	for i := range findings {
+		if findings[i].Rule == "" {
+			findings[i].Rule = r.name
+		}
+	}
+	return findings
+}
+
+// DefaultRules returns the rules shipped with this package.
+func DefaultRules() []Rule {
+	return []Rule{
+		ForwardWithoutLoop,
+		HealthWithoutLameduck,
+		FallthroughNotCovered,
+		CacheWithoutResolver,
+		DuplicatePlugin,
+		DuplicateServerBlock,
+		HostsEntries,
+	}
+}
+
+// Lint returns the findings of the rules in the Corefile, in rule order.
+func Lint(cf *corefile.Corefile, rules []Rule) []Finding {
+	findings := []Finding{}
+	for _, r := range rules {
+		findings = append(findings, r.Check(cf)...)
+	}
+	return findings
+}
+
+// LintString parses the Corefile and returns the findings of the rules in it.
+func LintString(corefileStr string, rules []Rule) ([]Finding, error) {
+	cf, err := corefile.New(corefileStr)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(cf, rules), nil
+}
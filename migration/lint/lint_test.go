@@ -0,0 +1,174 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		corefile string
+		expected []string
+	}{
+		{
+			name: "default kubernetes corefile",
+			corefile: `.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    ready
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`,
+			expected: []string{},
+		},
+		{
+			name: "forward without loop",
+			corefile: `.:53 {
+    forward . /etc/resolv.conf
+    cache 30
+}
+`,
+			expected: []string{
+				`[warning] forward-without-loop: server block ".:53", plugin "forward": forwarding without the loop plugin, forwarding loops will not be detected`,
+			},
+		},
+		{
+			name: "health without lameduck",
+			corefile: `.:53 {
+    health
+}
+`,
+			expected: []string{
+				`[warning] health-without-lameduck: server block ".:53", plugin "health": no lameduck duration set, queries may fail while CoreDNS shuts down`,
+			},
+		},
+		{
+			name: "fallthrough not covered",
+			corefile: `cluster.local:53 10.in-addr.arpa:53 {
+    kubernetes cluster.local 10.in-addr.arpa {
+        fallthrough 10.in-addr.arpa ip6.arpa
+    }
+}
+`,
+			expected: []string{
+				`[warning] fallthrough-not-covered: server block "cluster.local:53 10.in-addr.arpa:53", plugin "kubernetes", option "fallthrough": fallthrough zone 'ip6.arpa' is not served by the server block`,
+			},
+		},
+		{
+			name: "cache without resolver",
+			corefile: `example.org:53 {
+    errors
+    cache 30
+}
+`,
+			expected: []string{
+				`[warning] cache-without-resolver: server block "example.org:53", plugin "cache": no plugin in the server block produces answers to cache`,
+			},
+		},
+		{
+			name: "duplicate plugin",
+			corefile: `example.org:53 {
+    file db.example.org
+    file db.example.org.signed
+    log
+    log
+}
+`,
+			expected: []string{
+				`[error] duplicate-plugin: server block "example.org:53", plugin "log": plugin appears more than once in the server block`,
+			},
+		},
+		{
+			name: "duplicate server block",
+			corefile: `example.org {
+    whoami
+}
+dns://Example.org.:53 {
+    whoami
+}
+example.org:5353 tls://example.org {
+    whoami
+}
+`,
+			expected: []string{
+				`[error] duplicate-server-block: server block "dns://Example.org.:53": 'dns://Example.org.:53' is also served by server block "example.org"`,
+			},
+		},
+		{
+			name: "hosts entries",
+			corefile: `.:53 {
+    hosts {
+        10.0.0.1 a.example.org b.example.org
+        10.0.0.300 c.example.org
+        fe80::1%eth0 c.example.org
+        10.0.0.2
+        10.0.0.3 A.example.org.
+        ::ffff:10.0.0.1 b.example.org
+        fd00::1 a.example.org
+        fallthrough
+    }
+}
+`,
+			expected: []string{
+				`[error] hosts-entries: server block ".:53", plugin "hosts", option "10.0.0.300": invalid hosts entry '10.0.0.300': invalid address, the entry is ignored`,
+				`[error] hosts-entries: server block ".:53", plugin "hosts", option "fe80::1%eth0": invalid hosts entry 'fe80::1%eth0': address with a zone, the entry is ignored`,
+				`[error] hosts-entries: server block ".:53", plugin "hosts", option "10.0.0.2": invalid hosts entry '10.0.0.2': no names, the entry is ignored`,
+				`[warning] hosts-entries: server block ".:53", plugin "hosts", option "::ffff:10.0.0.1": 'b.example.org' is given more than once for ::ffff:10.0.0.1`,
+				`[warning] hosts-entries: server block ".:53", plugin "hosts": 'a.example.org.' is mapped to several addresses: 10.0.0.1, 10.0.0.3`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := LintString(tc.corefile, DefaultRules())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(findings) != len(tc.expected) {
+				t.Fatalf("expected %v findings, got %v: %v", len(tc.expected), len(findings), findings)
+			}
+			for i, f := range findings {
+				if f.ToString() != tc.expected[i] {
+					t.Errorf("expected '%v', got '%v'", tc.expected[i], f.ToString())
+				}
+			}
+		})
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	noLog := NewRule("no-log", func(cf *corefile.Corefile) []Finding {
+		findings := []Finding{}
+		for _, s := range cf.Servers {
+			for _, p := range s.Plugins {
+				if p.Name == "log" {
+					findings = append(findings, Finding{Severity: SevInfo, Server: s.Name(), Plugin: p.Name, Message: "logging every query"})
+				}
+			}
+		}
+		return findings
+	})
+
+	findings, err := LintString(".:53 {\n    log\n}\n", []Rule{noLog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Rule != "no-log" || !findings[0].Severity.AtLeast(SevInfo) || findings[0].Severity.AtLeast(SevWarning) {
+		t.Errorf("unexpected findings %v", findings)
+	}
+}
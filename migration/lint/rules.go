@@ -0,0 +1,221 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/corefile-migration/migration"
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// ForwardWithoutLoop finds server blocks forwarding queries without the loop plugin, so forwarding loops go
+// undetected.
+var ForwardWithoutLoop = NewRule("forward-without-loop", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		if s.FindPlugin("loop") != nil {
+			continue
+		}
+		for _, p := range s.Plugins {
+			if p.Name == "forward" || p.Name == "proxy" {
+				findings = append(findings, Finding{
+					Severity: SevWarning,
+					Server:   s.Name(),
+					Plugin:   p.Name,
+					Message:  "forwarding without the loop plugin, forwarding loops will not be detected",
+				})
+			}
+		}
+	}
+	return findings
+})
+
+// HealthWithoutLameduck finds health plugins without the lameduck option, so queries may fail while CoreDNS shuts
+// down.
+var HealthWithoutLameduck = NewRule("health-without-lameduck", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			if p.Name == "health" && p.Option("lameduck") == nil {
+				findings = append(findings, Finding{
+					Severity: SevWarning,
+					Server:   s.Name(),
+					Plugin:   p.Name,
+					Message:  "no lameduck duration set, queries may fail while CoreDNS shuts down",
+				})
+			}
+		}
+	}
+	return findings
+})
+
+// FallthroughNotCovered finds kubernetes fallthrough zones that are not served by the server block, so queries in
+// those zones never reach the kubernetes plugin.
+var FallthroughNotCovered = NewRule("fallthrough-not-covered", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		zones := []string{}
+		for _, dp := range s.DomPorts {
+			zones = append(zones, corefile.ParseServerAddr(dp).Zone)
+		}
+		for _, p := range s.Plugins {
+			if p.Name != "kubernetes" {
+				continue
+			}
+			for _, o := range p.Options {
+				if o.Name != "fallthrough" {
+					continue
+				}
+				for _, z := range o.Args {
+					if !coveredBy(corefile.NormalizeZone(z), zones) {
+						findings = append(findings, Finding{
+							Severity: SevWarning,
+							Server:   s.Name(),
+							Plugin:   p.Name,
+							Option:   o.Name,
+							Message:  fmt.Sprintf("fallthrough zone '%v' is not served by the server block", z),
+						})
+					}
+				}
+			}
+		}
+	}
+	return findings
+})
+
+// resolvers are the plugins producing answers for a cache to hold.
+var resolvers = []string{
+	"auto", "azure", "clouddns", "erratic", "etcd", "file", "forward", "grpc", "hosts", "k8s_external", "kubernetes",
+	"proxy", "route53", "secondary", "template", "whoami",
+}
+
+// CacheWithoutResolver finds cache plugins in server blocks without any plugin producing answers.
+var CacheWithoutResolver = NewRule("cache-without-resolver", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+NextServer:
+	for _, s := range cf.Servers {
+		if s.FindPlugin("cache") == nil {
+			continue
+		}
+		for _, r := range resolvers {
+			if s.FindPlugin(r) != nil {
+				continue NextServer
+			}
+		}
+		findings = append(findings, Finding{
+			Severity: SevWarning,
+			Server:   s.Name(),
+			Plugin:   "cache",
+			Message:  "no plugin in the server block produces answers to cache",
+		})
+	}
+	return findings
+})
+
+// multiplePlugins are the plugins that may appear more than once in a server block.
+var multiplePlugins = map[string]bool{"acl": true, "bind": true, "file": true, "rewrite": true, "template": true}
+
+// DuplicatePlugin finds plugins appearing more than once in a server block, which CoreDNS rejects for most plugins.
+var DuplicatePlugin = NewRule("duplicate-plugin", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		seen := map[string]bool{}
+		for _, p := range s.Plugins {
+			if seen[p.Name] && !multiplePlugins[p.Name] {
+				findings = append(findings, Finding{
+					Severity: SevError,
+					Server:   s.Name(),
+					Plugin:   p.Name,
+					Message:  "plugin appears more than once in the server block",
+				})
+			}
+			seen[p.Name] = true
+		}
+	}
+	return findings
+})
+
+// DuplicateServerBlock finds zone/port pairs served by more than one server block, which CoreDNS rejects.
+var DuplicateServerBlock = NewRule("duplicate-server-block", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	seen := map[corefile.ServerAddr]string{}
+	for _, s := range cf.Servers {
+		for _, dp := range s.DomPorts {
+			za := corefile.ParseServerAddr(dp)
+			if first, ok := seen[za]; ok {
+				findings = append(findings, Finding{
+					Severity: SevError,
+					Server:   s.Name(),
+					Message:  fmt.Sprintf("'%v' is also served by server block \"%v\"", dp, first),
+				})
+				continue
+			}
+			seen[za] = s.Name()
+		}
+	}
+	return findings
+})
+
+// HostsEntries finds inline entries of hosts plugins that CoreDNS ignores, i.e. entries with an invalid address or
+// without names, and names given more than once or mapped to different addresses of the same family.
+var HostsEntries = NewRule("hosts-entries", func(cf *corefile.Corefile) []Finding {
+	findings := []Finding{}
+	for _, s := range cf.Servers {
+		for _, p := range s.Plugins {
+			if p.Name != "hosts" {
+				continue
+			}
+			h := &migration.Hosts{}
+			for _, o := range p.Options {
+				if migration.IsHostsOption(o.Name) {
+					continue
+				}
+				e, err := migration.ParseHostsEntry(o)
+				if err != nil {
+					findings = append(findings, Finding{
+						Severity: SevError,
+						Server:   s.Name(),
+						Plugin:   p.Name,
+						Option:   o.Name,
+						Message:  fmt.Sprintf("%v, the entry is ignored", err),
+					})
+					continue
+				}
+				h.Entries = append(h.Entries, e)
+			}
+			for _, e := range h.Duplicates() {
+				findings = append(findings, Finding{
+					Severity: SevWarning,
+					Server:   s.Name(),
+					Plugin:   p.Name,
+					Option:   e.Addr.String(),
+					Message:  fmt.Sprintf("'%v' is given more than once for %v", e.Names[0], e.Addr),
+				})
+			}
+			for _, c := range h.Conflicts() {
+				addrs := []string{}
+				for _, a := range c.Addrs {
+					addrs = append(addrs, a.String())
+				}
+				findings = append(findings, Finding{
+					Severity: SevWarning,
+					Server:   s.Name(),
+					Plugin:   p.Name,
+					Message:  fmt.Sprintf("'%v' is mapped to several addresses: %v", c.Name, strings.Join(addrs, ", ")),
+				})
+			}
+		}
+	}
+	return findings
+})
+
+// coveredBy returns true if the zone is equal to, or a sub zone of, any of the zones.  Zones given in CIDR notation
+// are assumed to cover any zone, since the reverse zones they expand to are not computed.
+func coveredBy(zone string, zones []string) bool {
+	for _, z := range zones {
+		if z == "." || z == zone || strings.HasSuffix(zone, "."+z) || strings.Contains(z, "/") {
+			return true
+		}
+	}
+	return false
+}
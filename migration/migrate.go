@@ -6,12 +6,7 @@ package migration
 // helper functions that make this easier to implement.
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
 
 	"github.com/coredns/corefile-migration/migration/corefile"
 )
@@ -20,23 +15,50 @@ import (
 // any deprecated, removed, or ignored plugins/directives present in the Corefile.  Notifications are also returned for
 // any new default plugins that would be added in a migration.
 func Deprecated(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Notice, error) {
+	return defaultMigrator.Deprecated(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+}
+
+// Deprecated returns a list of deprecation notifications affecting the given Corefile, using the Migrator's catalog.
+func (m *Migrator) Deprecated(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Notice, error) {
 	if fromCoreDNSVersion == toCoreDNSVersion {
 		return nil, nil
 	}
-	return getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, SevAll)
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return nil, nil
+	}
+	return m.getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, false)
 }
 
 // Unsupported returns a list notifications of plugins/options that are not handled supported by this migration tool,
 // but may still be valid in CoreDNS.
 func Unsupported(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Notice, error) {
+	return defaultMigrator.Unsupported(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+}
+
+// Unsupported returns a list of notifications of plugins/options that are not handled by the Migrator's catalog,
+// but may still be valid in CoreDNS.
+func (m *Migrator) Unsupported(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) ([]Notice, error) {
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return nil, nil
+	}
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
 	if fromCoreDNSVersion == toCoreDNSVersion {
 		return nil, nil
 	}
-	return getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, SevUnsupported)
+	return m.getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, true)
 }
 
-func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string) ([]Notice, error) {
-	err := ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion)
+// getStatus returns the notices raised by migrating the Corefile.  If unsupported is true, only notices of
+// plugins/options unsupported by the migration tool are returned, otherwise only those are left out.
+func (m *Migrator) getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, unsupported bool) ([]Notice, error) {
+	err := m.ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -48,21 +70,24 @@ func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string)
 	v := fromCoreDNSVersion
 	for {
 		if fromCoreDNSVersion != toCoreDNSVersion {
-			v = Versions[v].nextVersion
+			v = m.catalog[v].nextVersion
 		}
+		cps := m.compiledPlugins(v)
 		for _, s := range cf.Servers {
+			server := s.Name()
 			for _, p := range s.Plugins {
-				vp, present := Versions[v].plugins[p.Name]
-				if status == SevUnsupported && !present {
-					notices = append(notices, Notice{Plugin: p.Name, Severity: status, Version: v})
+				vp, present := m.catalog[v].plugins[p.Name]
+				if unsupported && !present {
+					notices = append(notices, Notice{Plugin: p.Name, Server: server, Severity: SevUnsupported, Version: v})
 					continue
 				}
 				if !present {
 					continue
 				}
-				if vp.status != "" && vp.status != SevNewDefault && status != SevUnsupported {
+				if vp.status != "" && vp.status != SevNewDefault && !unsupported {
 					notices = append(notices, Notice{
 						Plugin:     p.Name,
+						Server:     server,
 						Severity:   vp.status,
 						Version:    v,
 						ReplacedBy: vp.replacedBy,
@@ -70,16 +95,21 @@ func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string)
 					})
 					continue
 				}
+				if vp.rules != nil {
+					notices = append(notices, ruleNotices(p, vp, server, v, unsupported)...)
+					continue
+				}
 				for _, o := range p.Options {
-					vo, present := matchOption(o.Name, Versions[v].plugins[p.Name])
-					if status == SevUnsupported {
+					vo, present := cps[p.Name].matchOption(o)
+					if unsupported {
 						if present {
 							continue
 						}
 						notices = append(notices, Notice{
 							Plugin:   p.Name,
 							Option:   o.Name,
-							Severity: status,
+							Server:   server,
+							Severity: SevUnsupported,
 							Version:  v,
 						})
 						continue
@@ -88,28 +118,28 @@ func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string)
 						continue
 					}
 					if vo.status != "" && vo.status != SevNewDefault {
-						notices = append(notices, Notice{Plugin: p.Name, Option: o.Name, Severity: vo.status, Version: v})
+						notices = append(notices, Notice{Plugin: p.Name, Option: vo.name, Server: server, Severity: vo.status, Version: v})
 						continue
 					}
 				}
-				if status != SevUnsupported {
+				if !unsupported {
 				CheckForNewOptions:
-					for name, vo := range Versions[v].plugins[p.Name].namedOptions {
+					for name, vo := range m.catalog[v].plugins[p.Name].namedOptions {
 						if vo.status != SevNewDefault {
 							continue
 						}
 						for _, o := range p.Options {
-							if name == o.Name {
+							if optionNamed(o, name) {
 								continue CheckForNewOptions
 							}
 						}
-						notices = append(notices, Notice{Plugin: p.Name, Option: name, Severity: SevNewDefault, Version: v})
+						notices = append(notices, Notice{Plugin: p.Name, Option: name, Server: server, Severity: SevNewDefault, Version: v})
 					}
 				}
 			}
-			if status != SevUnsupported {
+			if !unsupported {
 			CheckForNewPlugins:
-				for name, vp := range Versions[v].plugins {
+				for name, vp := range m.catalog[v].plugins {
 					if vp.status != SevNewDefault {
 						continue
 					}
@@ -118,7 +148,7 @@ func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string)
 							continue CheckForNewPlugins
 						}
 					}
-					notices = append(notices, Notice{Plugin: name, Option: "", Severity: SevNewDefault, Version: v})
+					notices = append(notices, Notice{Plugin: name, Option: "", Server: server, Severity: SevNewDefault, Version: v})
 				}
 			}
 		}
@@ -134,147 +164,268 @@ func getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, status string)
 // If deprecations is true, deprecated plugins/options will be migrated as soon as they are deprecated.
 // If deprecations is false, deprecated plugins/options will be migrated only once they become removed or ignored.
 func Migrate(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, deprecations bool) (string, error) {
+	return defaultMigrator.Migrate(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, deprecations)
+}
+
+// Migrate returns the Corefile converted to toCoreDNSVersion using the Migrator's catalog, or an error if it cannot.
+func (m *Migrator) Migrate(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, deprecations bool) (string, error) {
+	return m.MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, MigrateOptions{Deprecations: deprecations})
+}
+
+// MigrateWithOptions returns the Corefile converted to toCoreDNSVersion, or an error if it cannot.  It behaves like
+// Migrate, with the handling of deprecations, unknown plugins/options, new defaults, server block splitting and
+// notice severities controlled by opts.
+func MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, opts MigrateOptions) (string, error) {
+	return defaultMigrator.MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, opts)
+}
+
+// MigrateWithOptions returns the Corefile converted to toCoreDNSVersion using the Migrator's catalog, or an error if
+// it cannot.
+func (m *Migrator) MigrateWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, opts MigrateOptions) (string, error) {
 	if fromCoreDNSVersion == toCoreDNSVersion {
 		return corefileStr, nil
 	}
-	err := ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion)
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
 	if err != nil {
 		return "", err
 	}
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return corefileStr, nil
+	}
+	plan, err := m.plan(fromCoreDNSVersion, toCoreDNSVersion, opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.AbortSeverity != "" {
+		err = m.checkSeverity(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, opts.AbortSeverity)
+		if err != nil {
+			return "", err
+		}
+	}
 	cf, err := corefile.New(corefileStr)
 	if err != nil {
 		return "", err
 	}
-	v := fromCoreDNSVersion
-	for {
-		v = Versions[v].nextVersion
+	var errs MigrationErrors
+	for _, step := range plan.steps {
+		cf, err = m.migrateStep(cf, step, opts, &errs)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(errs) > 0 {
+		return cf.ToString(), errs
+	}
+	return cf.ToString(), nil
+}
 
-		// apply any global corefile level pre-processing
-		if Versions[v].preProcess != nil {
-			cf, err = Versions[v].preProcess(cf)
-			if err != nil {
-				return "", err
-			}
+// migrateStep migrates the Corefile to the version of the step from the version directly preceding it.  If
+// opts.ContinueOnError is set, errors are collected in errs and the affected server blocks/plugins are left untouched,
+// otherwise the first error is returned.
+func (m *Migrator) migrateStep(cf *corefile.Corefile, step *migrationStep, opts MigrateOptions, errs *MigrationErrors) (*corefile.Corefile, error) {
+	v := step.version
+	fail := func(err error) error {
+		if !opts.ContinueOnError {
+			return withVersion(err, v)
 		}
+		errs.add(err, v)
+		return nil
+	}
 
-		newSrvs := []*corefile.Server{}
-		for _, s := range cf.Servers {
-			newPlugs := []*corefile.Plugin{}
-			for _, p := range s.Plugins {
-				vp, present := Versions[v].plugins[p.Name]
-				if !present {
-					newPlugs = append(newPlugs, p)
-					continue
-				}
-				if !deprecations && vp.status == SevDeprecated {
-					newPlugs = append(newPlugs, p)
-					continue
-				}
-				newOpts := []*corefile.Option{}
-				for _, o := range p.Options {
-					vo, present := matchOption(o.Name, Versions[v].plugins[p.Name])
-					if !present {
-						newOpts = append(newOpts, o)
-						continue
-					}
-					if !deprecations && vo.status == SevDeprecated {
-						newOpts = append(newOpts, o)
-						continue
-					}
-					if vo.action == nil {
-						newOpts = append(newOpts, o)
-						continue
-					}
-					o, err := vo.action(o)
-					if err != nil {
-						return "", err
-					}
-					if o == nil {
-						// remove option
-						continue
-					}
-					newOpts = append(newOpts, o)
-				}
-				if vp.action != nil {
-					p, err := vp.action(p)
-					if err != nil {
-						return "", err
-					}
-					if p == nil {
-						// remove plugin, skip options processing
-						continue
-					}
-				}
-				newPlug := &corefile.Plugin{
-					Name:    p.Name,
-					Args:    p.Args,
-					Options: newOpts,
-				}
-			CheckForNewOptions:
-				for name, vo := range Versions[v].plugins[p.Name].namedOptions {
-					if vo.status != SevNewDefault {
-						continue
-					}
-					for _, o := range p.Options {
-						if name == o.Name {
-							continue CheckForNewOptions
-						}
-					}
-					newPlug, err = vo.add(newPlug)
-					if err != nil {
-						return "", err
-					}
-				}
+	// apply any global corefile level pre-processing
+	if step.release.preProcess != nil {
+		var err error
+		cf, err = m.applyCorefileAction(cf, step.release.preProcess, opts, fail)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-				newPlugs = append(newPlugs, newPlug)
+	newSrvs := []*corefile.Server{}
+	for _, s := range cf.Servers {
+		newPlugs := []*corefile.Plugin{}
+		for _, p := range s.Plugins {
+			var orig *corefile.Plugin
+			if opts.ContinueOnError {
+				orig = p.Clone()
 			}
-			newSrv := &corefile.Server{
-				DomPorts: s.DomPorts,
-				Plugins:  newPlugs,
-			}
-		CheckForNewPlugins:
-			for name, vp := range Versions[v].plugins {
-				if vp.status != SevNewDefault {
-					continue
+			newPlug, err := m.migratePlugin(s, p, step.plugins[p.Name], opts)
+			if err != nil {
+				if err := fail(err); err != nil {
+					return nil, err
 				}
-				for _, p := range s.Plugins {
-					if name == p.Name {
-						continue CheckForNewPlugins
-					}
+				// leave the plugin untouched
+				newPlugs = append(newPlugs, orig)
+				continue
+			}
+			if newPlug == nil {
+				// remove plugin
+				continue
+			}
+			newPlugs = append(newPlugs, newPlug)
+		}
+		newSrv := &corefile.Server{
+			DomPorts:    s.DomPorts,
+			Plugins:     newPlugs,
+			Comments:    s.Comments,
+			Comment:     s.Comment,
+			EndComments: s.EndComments,
+			EndComment:  s.EndComment,
+		}
+	CheckForNewPlugins:
+		for _, name := range step.newPlugins {
+			for _, p := range s.Plugins {
+				if name == p.Name {
+					continue CheckForNewPlugins
 				}
-				newSrv, err = vp.add(newSrv)
-				if err != nil {
-					return "", err
+			}
+			added, err := step.plugins[name].add(newSrv)
+			if err != nil {
+				if err := fail(&MigrationError{Server: s.Name(), Plugin: name, Err: err}); err != nil {
+					return nil, err
 				}
+				continue
 			}
+			newSrv = added
+		}
 
-			newSrvs = append(newSrvs, newSrv)
+		newSrvs = append(newSrvs, newSrv)
+	}
+
+	cf = &corefile.Corefile{Servers: newSrvs, EndComments: cf.EndComments}
+
+	// apply any global corefile level post processing
+	if step.release.postProcess != nil {
+		var err error
+		cf, err = m.applyCorefileAction(cf, step.release.postProcess, opts, fail)
+		if err != nil {
+			return nil, err
 		}
+	}
+	return cf, nil
+}
 
-		cf = &corefile.Corefile{Servers: newSrvs}
+// applyCorefileAction applies a corefile level pre/post processing action.  Errors are passed to fail, and if the
+// action does not return a partial result, the Corefile is left untouched.
+func (m *Migrator) applyCorefileAction(cf *corefile.Corefile, action corefileAction, opts MigrateOptions, fail func(error) error) (*corefile.Corefile, error) {
+	orig := cf.Clone()
+	newCf, err := action(cf, opts)
+	if err != nil {
+		if err := fail(err); err != nil {
+			return nil, err
+		}
+		if newCf == nil {
+			return orig, nil
+		}
+	}
+	return newCf, nil
+}
 
-		// apply any global corefile level post processing
-		if Versions[v].postProcess != nil {
-			cf, err = Versions[v].postProcess(cf)
-			if err != nil {
-				return "", err
+// migratePlugin returns the plugin migrated by its catalog entry cp, or nil if the plugin is removed.  cp is nil if the
+// plugin is not in the catalog.
+func (m *Migrator) migratePlugin(s *corefile.Server, p *corefile.Plugin, cp *compiledPlugin, opts MigrateOptions) (*corefile.Plugin, error) {
+	if cp == nil {
+		if opts.UnknownPlugins == UnknownError {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Err: ErrUnsupported}
+		}
+		return p, nil
+	}
+	vp := cp.plugin
+	if !opts.Deprecations && vp.status == SevDeprecated {
+		return p, nil
+	}
+	if cp.noop && opts.UnknownOptions != UnknownError {
+		// fast path, nothing to migrate nor to check
+		return p, nil
+	}
+	if vp.rules != nil {
+		// the options of the rewrite plugin are part of its rule
+		return migrateRule(s, p, vp, opts)
+	}
+	newOpts := []*corefile.Option{}
+	for _, o := range p.Options {
+		vo, present := cp.matchOption(o)
+		if !present {
+			if opts.UnknownOptions == UnknownError {
+				return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Option: o.Name, Err: ErrUnsupported}
 			}
+			newOpts = append(newOpts, o)
+			continue
 		}
-
-		if v == toCoreDNSVersion {
-			break
+		if !opts.Deprecations && vo.status == SevDeprecated {
+			newOpts = append(newOpts, o)
+			continue
+		}
+		if vo.action == nil {
+			newOpts = append(newOpts, o)
+			continue
 		}
+		o, err := vo.action(o)
+		if err != nil {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Option: vo.name, Err: err}
+		}
+		if o == nil {
+			// remove option
+			continue
+		}
+		newOpts = append(newOpts, o)
 	}
-	return cf.ToString(), nil
+	if vp.action != nil {
+		name := p.Name
+		p, err := vp.action(p)
+		if err != nil {
+			return nil, &MigrationError{Server: s.Name(), Plugin: name, Err: err}
+		}
+		if p == nil {
+			// remove plugin, skip options processing
+			return nil, nil
+		}
+	}
+	newPlug := &corefile.Plugin{
+		Name:        p.Name,
+		Args:        p.Args,
+		Options:     newOpts,
+		Comments:    p.Comments,
+		Comment:     p.Comment,
+		EndComments: p.EndComments,
+		EndComment:  p.EndComment,
+	}
+CheckForNewOptions:
+	for _, name := range cp.newOptions {
+		for _, o := range p.Options {
+			if optionNamed(o, name) {
+				continue CheckForNewOptions
+			}
+		}
+		var err error
+		newPlug, err = vp.namedOptions[name].add(newPlug)
+		if err != nil {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Option: name, Err: err}
+		}
+	}
+	return newPlug, nil
 }
 
 // MigrateDown returns the Corefile converted to toCoreDNSVersion, or an error if it cannot. This function only accepts
 // a downward migration, where the destination version is <= the start version.
 func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (string, error) {
+	return defaultMigrator.MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+}
+
+// MigrateDown returns the Corefile converted down to toCoreDNSVersion using the Migrator's catalog, or an error if it
+// cannot.
+func (m *Migrator) MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (string, error) {
 	if fromCoreDNSVersion == toCoreDNSVersion {
 		return corefileStr, nil
 	}
-	err := validDownMigration(fromCoreDNSVersion, toCoreDNSVersion)
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return "", err
+	}
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return corefileStr, nil
+	}
+	err = m.validDownMigration(fromCoreDNSVersion, toCoreDNSVersion)
 	if err != nil {
 		return "", err
 	}
@@ -284,22 +435,34 @@ func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (stri
 	}
 	v := fromCoreDNSVersion
 	for {
+		cps := m.compiledPlugins(v)
 		newSrvs := []*corefile.Server{}
 		for _, s := range cf.Servers {
 			newPlugs := []*corefile.Plugin{}
 			for _, p := range s.Plugins {
-				vp, present := Versions[v].plugins[p.Name]
+				vp, present := m.catalog[v].plugins[p.Name]
 				if !present {
 					newPlugs = append(newPlugs, p)
 					continue
 				}
+				if vp.rules != nil {
+					newP, err := migrateRuleDown(s, p, vp)
+					if err != nil {
+						return "", withVersion(err, v)
+					}
+					if newP != nil {
+						newPlugs = append(newPlugs, newP)
+					}
+					continue
+				}
 				if vp.downAction == nil {
 					newPlugs = append(newPlugs, p)
 					continue
 				}
+				name := p.Name
 				p, err := vp.downAction(p)
 				if err != nil {
-					return "", err
+					return "", &MigrationError{Version: v, Server: s.Name(), Plugin: name, Err: err}
 				}
 				if p == nil {
 					// remove plugin, skip options processing
@@ -308,7 +471,7 @@ func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (stri
 
 				newOpts := []*corefile.Option{}
 				for _, o := range p.Options {
-					vo, present := matchOption(o.Name, Versions[v].plugins[p.Name])
+					vo, present := cps[p.Name].matchOption(o)
 					if !present {
 						newOpts = append(newOpts, o)
 						continue
@@ -319,7 +482,7 @@ func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (stri
 					}
 					o, err := vo.downAction(o)
 					if err != nil {
-						return "", err
+						return "", &MigrationError{Version: v, Server: s.Name(), Plugin: p.Name, Option: vo.name, Err: err}
 					}
 					if o == nil {
 						// remove option
@@ -328,25 +491,33 @@ func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (stri
 					newOpts = append(newOpts, o)
 				}
 				newPlug := &corefile.Plugin{
-					Name:    p.Name,
-					Args:    p.Args,
-					Options: newOpts,
+					Name:        p.Name,
+					Args:        p.Args,
+					Options:     newOpts,
+					Comments:    p.Comments,
+					Comment:     p.Comment,
+					EndComments: p.EndComments,
+					EndComment:  p.EndComment,
 				}
 				newPlugs = append(newPlugs, newPlug)
 			}
 			newSrv := &corefile.Server{
-				DomPorts: s.DomPorts,
-				Plugins:  newPlugs,
+				DomPorts:    s.DomPorts,
+				Plugins:     newPlugs,
+				Comments:    s.Comments,
+				Comment:     s.Comment,
+				EndComments: s.EndComments,
+				EndComment:  s.EndComment,
 			}
 			newSrvs = append(newSrvs, newSrv)
 		}
 
-		cf = &corefile.Corefile{Servers: newSrvs}
+		cf = &corefile.Corefile{Servers: newSrvs, EndComments: cf.EndComments}
 
 		if v == toCoreDNSVersion {
 			break
 		}
-		v = Versions[v].priorVersion
+		v = m.catalog[v].priorVersion
 	}
 	return cf.ToString(), nil
 }
@@ -354,12 +525,17 @@ func MigrateDown(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (stri
 // Default returns true if the Corefile is the default for a given version of Kubernetes.
 // Or, if k8sVersion is empty, Default returns true if the Corefile is the default for any version of Kubernetes.
 func Default(k8sVersion, corefileStr string) bool {
+	return defaultMigrator.Default(k8sVersion, corefileStr)
+}
+
+// Default returns true if the Corefile is the default for a given version of Kubernetes in the Migrator's catalog.
+func (m *Migrator) Default(k8sVersion, corefileStr string) bool {
 	cf, err := corefile.New(corefileStr)
 	if err != nil {
 		return false
 	}
 NextVersion:
-	for _, v := range Versions {
+	for _, v := range m.catalog {
 		for _, release := range v.k8sReleases {
 			if k8sVersion != "" && k8sVersion != release {
 				continue
@@ -404,107 +580,108 @@ NextVersion:
 
 // Released returns true if dockerImageSHA matches any released image of CoreDNS.
 func Released(dockerImageSHA string) bool {
-	for _, v := range Versions {
-		if v.dockerImageSHA == dockerImageSHA {
-			return true
-		}
-	}
-	return false
+	return defaultMigrator.Released(dockerImageSHA)
+}
+
+// Released returns true if dockerImageSHA matches any release in the Migrator's catalog.  The SHA may have a
+// "sha256:" prefix, and may be the digest of the manifest list or of any of the per-platform images of a release.
+func (m *Migrator) Released(dockerImageSHA string) bool {
+	_, err := m.VersionFromSHA(dockerImageSHA)
+	return err == nil
 }
 
 // VersionFromSHA returns the version string matching the dockerImageSHA.
 func VersionFromSHA(dockerImageSHA string) (string, error) {
-	for vStr, v := range Versions {
-		if v.dockerImageSHA == dockerImageSHA {
-			return vStr, nil
+	return defaultMigrator.VersionFromSHA(dockerImageSHA)
+}
+
+// VersionFromSHA returns the version string in the Migrator's catalog matching the dockerImageSHA.  The SHA may have
+// a "sha256:" prefix, and may be the digest of the manifest list or of any of the per-platform images of a release.
+func (m *Migrator) VersionFromSHA(dockerImageSHA string) (string, error) {
+	sha := normalizeDigest(dockerImageSHA)
+	if sha != "" {
+		for vStr, v := range m.catalog {
+			if v.dockerImageSHA == sha {
+				return vStr, nil
+			}
+			for _, d := range v.platformDigests {
+				if d == sha {
+					return vStr, nil
+				}
+			}
 		}
 	}
-	return "", errors.New("sha unsupported")
+	return "", fmt.Errorf("%w: %v", ErrUnknownSHA, dockerImageSHA)
 }
 
 // ValidVersions returns a list of all versions defined
 func ValidVersions() []string {
+	return defaultMigrator.ValidVersions()
+}
+
+// ValidVersions returns a list of all versions defined in the Migrator's catalog, sorted in ascending order
+func (m *Migrator) ValidVersions() []string {
 	var vStrs []string
-	for vStr := range Versions {
+	for vStr := range m.catalog {
 		vStrs = append(vStrs, vStr)
 	}
-	sort.Slice(vStrs, func(i, j int) bool {
-		iSegs := strings.Split(vStrs[i], ".")
-		jSegs := strings.Split(vStrs[j], ".")
-		for k, iSeg := range iSegs {
-			if iSeg == jSegs[k] {
-				continue
-			}
-			iInt, err := strconv.Atoi(iSeg)
-			if err != nil {
-				panic(err)
-			}
-			jInt, err := strconv.Atoi(jSegs[k])
-			if err != nil {
-				panic(err)
-			}
-			return iInt < jInt
-		}
-		return false
-	})
-
+	sortVersions(vStrs)
 	return vStrs
 }
 
+// ValidUpMigration returns an error if toCoreDNSVersion cannot be reached by an upward migration from
+// fromCoreDNSVersion.
 func ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion string) error {
+	return defaultMigrator.ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion)
+}
 
-	err := validateVersion(fromCoreDNSVersion)
+// ValidUpMigration returns an error if toCoreDNSVersion cannot be reached by an upward migration from
+// fromCoreDNSVersion in the Migrator's catalog.
+func (m *Migrator) ValidUpMigration(fromCoreDNSVersion, toCoreDNSVersion string) error {
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
 	if err != nil {
 		return err
 	}
 	if fromCoreDNSVersion == toCoreDNSVersion {
 		return nil
 	}
-	for next := Versions[fromCoreDNSVersion].nextVersion; next != ""; next = Versions[next].nextVersion {
+	for next := m.catalog[fromCoreDNSVersion].nextVersion; next != ""; next = m.catalog[next].nextVersion {
+		if _, ok := m.catalog[next]; !ok {
+			// the release chain leaves the catalog
+			break
+		}
 		if next != toCoreDNSVersion {
 			continue
 		}
 		return nil
 	}
-	return fmt.Errorf("cannot migrate up to '%v' from '%v'", toCoreDNSVersion, fromCoreDNSVersion)
+	return fmt.Errorf("%w: cannot migrate up to '%v' from '%v'", ErrInvalidDirection, toCoreDNSVersion, fromCoreDNSVersion)
 }
 
-func validateVersion(fromCoreDNSVersion string) error {
-	if _, ok := Versions[fromCoreDNSVersion]; !ok {
-		return fmt.Errorf("start version '%v' not supported", fromCoreDNSVersion)
-	}
-	return nil
+func validDownMigration(fromCoreDNSVersion, toCoreDNSVersion string) error {
+	return defaultMigrator.validDownMigration(fromCoreDNSVersion, toCoreDNSVersion)
 }
 
-func validDownMigration(fromCoreDNSVersion, toCoreDNSVersion string) error {
-	err := validateVersion(fromCoreDNSVersion)
+func (m *Migrator) validDownMigration(fromCoreDNSVersion, toCoreDNSVersion string) error {
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
 	if err != nil {
 		return err
 	}
-	for prior := Versions[fromCoreDNSVersion].priorVersion; prior != ""; prior = Versions[prior].priorVersion {
+	for prior := m.catalog[fromCoreDNSVersion].priorVersion; prior != ""; prior = m.catalog[prior].priorVersion {
+		if _, ok := m.catalog[prior]; !ok {
+			// the release chain leaves the catalog
+			break
+		}
 		if prior != toCoreDNSVersion {
 			continue
 		}
 		return nil
 	}
-	return fmt.Errorf("cannot migrate down to '%v' from '%v'", toCoreDNSVersion, fromCoreDNSVersion)
+	return fmt.Errorf("%w: cannot migrate down to '%v' from '%v'", ErrInvalidDirection, toCoreDNSVersion, fromCoreDNSVersion)
 }
 
-func matchOption(oName string, p plugin) (*option, bool) {
-	o, exists := p.namedOptions[oName]
-	if exists {
-		o.name = oName
-		return &o, exists
-	}
-	for pattern, o := range p.patternOptions {
-		matched, err := regexp.MatchString(pattern, oName)
-		if err != nil {
-			continue
-		}
-		if matched {
-			o.name = oName
-			return &o, true
-		}
-	}
-	return nil, false
+// optionNamed returns true if the option is named name, by its name only or followed by its first argument (see
+// compiledPlugin.matchOption).
+func optionNamed(o *corefile.Option, name string) bool {
+	return name == o.Name || (len(o.Args) > 0 && name == o.Name+" "+o.Args[0])
 }
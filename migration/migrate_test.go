@@ -1,7 +1,10 @@
 package migration
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
 )
 
 func TestMigrate(t *testing.T) {
@@ -388,18 +391,137 @@ func TestMigrate(t *testing.T) {
 }
 
 mystub-1.example.org {
+    errors
     forward . 1.2.3.4
+    cache 30
     loop
+}
+
+mystub-2.example.org {
     errors
+    forward . 5.6.7.8
     cache 30
+    loop
+}
+`,
+		},
+		{
+			name:         "merge stub domains into existing server blocks",
+			fromVersion:  "1.3.1",
+			toVersion:    "1.4.0",
+			deprecations: true,
+			startCorefile: `.:53 {
+    errors
+    proxy example.org 1.2.3.4
+    proxy example.net 5.6.7.8
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
 }
 
-mystub-2.example.org {
+example.org:53 {
+    errors
+    cache 60
+}
+`,
+			expectedCorefile: `.:53 {
+    errors
+    forward . /etc/resolv.conf
+    cache 30
+    loop
+}
+
+example.org:53 {
+    errors
+    cache 60
+    forward . 1.2.3.4
+    loop
+}
+
+example.net {
+    errors
     forward . 5.6.7.8
+    cache 30
     loop
+}
+`,
+		},
+		{
+			name:         "stub domain served on several ports by its own server block",
+			fromVersion:  "1.3.1",
+			toVersion:    "1.4.0",
+			deprecations: true,
+			startCorefile: `example.org:53 example.org:5353 {
+    errors
+    proxy example.org 1.2.3.4
+    cache 30
+}
+`,
+			expectedCorefile: `example.org:53 example.org:5353 {
     errors
+    forward . 1.2.3.4
     cache 30
 }
+`,
+		},
+		{
+			name:         "stub domain merged into a server block serving it on several ports",
+			fromVersion:  "1.3.1",
+			toVersion:    "1.4.0",
+			deprecations: true,
+			startCorefile: `.:53 .:5353 {
+    errors
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+    cache 30
+}
+
+example.org:53 example.org:5353 {
+    log
+}
+`,
+			expectedCorefile: `.:53 .:5353 {
+    errors
+    forward . /etc/resolv.conf
+    cache 30
+}
+
+example.org:53 example.org:5353 {
+    log
+    errors
+    forward . 1.2.3.4
+    cache 30
+}
+`,
+		},
+		{
+			name:         "carry stub domain ports and sibling plugins",
+			fromVersion:  "1.3.1",
+			toVersion:    "1.4.0",
+			deprecations: true,
+			startCorefile: `.:5353 tls://.:853 {
+    bind 10.0.0.1
+    log
+    health
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+    loop
+}
+`,
+			expectedCorefile: `.:5353 tls://.:853 {
+    bind 10.0.0.1
+    log
+    health
+    forward . /etc/resolv.conf
+    loop
+}
+
+example.org:5353 tls://example.org {
+    bind 10.0.0.1
+    log
+    forward . 1.2.3.4
+    loop
+}
 `,
 		},
 		{
@@ -910,6 +1032,19 @@ func TestUnsupported(t *testing.T) {
 				{Plugin: "invalid", Severity: SevUnsupported, Version: "1.6.7"},
 			},
 		},
+		{
+			name: "Cache serve_stale option",
+			startCorefile: `.:53 {
+    forward . /etc/resolv.conf
+    cache 30 {
+        serve_stale
+    }
+}
+`,
+			fromVersion: "1.6.6",
+			toVersion:   "1.9.4",
+			expected:    []Notice{},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1110,32 +1245,36 @@ func TestValidDownMigration(t *testing.T) {
 func TestMatchOption(t *testing.T) {
 	o := option{}
 	p := plugin{
-		namedOptions:   map[string]option{"named-option": o},
+		namedOptions:   map[string]option{"named-option": o, "multi word": o, "multi": o},
 		patternOptions: map[string]option{"pattern-option-[A-Z]+[0-9]+": o},
 	}
 
 	tests := []struct {
-		name    string
-		matched bool
+		option   *corefile.Option
+		matched  bool
+		expected string
 	}{
-		{"named-option", true},
-		{"qwerty", false},
-		{"pattern-option-A10", true},
-		{"pattern-option-a10", false},
+		{option: &corefile.Option{Name: "named-option"}, matched: true, expected: "named-option"},
+		{option: &corefile.Option{Name: "qwerty"}, matched: false},
+		{option: &corefile.Option{Name: "pattern-option-A10"}, matched: true, expected: "pattern-option-A10"},
+		{option: &corefile.Option{Name: "pattern-option-a10"}, matched: false},
+		{option: &corefile.Option{Name: "multi", Args: []string{"word", "arg"}}, matched: true, expected: "multi word"},
+		{option: &corefile.Option{Name: "multi", Args: []string{"arg"}}, matched: true, expected: "multi"},
+		{option: &corefile.Option{Name: "named-option", Args: []string{"word"}}, matched: true, expected: "named-option"},
 	}
 	for _, test := range tests {
-		gotopt, matched := matchOption(test.name, p)
+		gotopt, matched := compilePlugin(p, MigrateOptions{}).matchOption(test.option)
 		if matched != test.matched {
-			t.Fatalf("expected %v to match plugin option", test.name)
+			t.Fatalf("expected %v to match plugin option", test.option.ToString())
 		}
-		if matched == test.matched && !test.matched {
+		if !test.matched {
 			continue
 		}
 		if gotopt == nil {
 			t.Fatal("expected non-nil returned option")
 		}
-		if gotopt.name != test.name {
-			t.Fatalf("expected returned option name == '%v' got '%v'", test.name, gotopt.name)
+		if gotopt.name != test.expected {
+			t.Fatalf("expected returned option name == '%v' got '%v'", test.expected, gotopt.name)
 		}
 	}
 
@@ -1165,3 +1304,520 @@ func TestVersionFromSHA(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog := DefaultCatalog()
+	r := catalog["1.11.1"]
+	delete(r.plugins, "forward")
+	r.plugins["kubernetes"].namedOptions["pods"] = option{status: SevRemoved}
+	r.k8sReleases[0] = "0.0"
+
+	if _, ok := Versions["1.11.1"].plugins["forward"]; !ok {
+		t.Error("expected the forward plugin to be kept in Versions")
+	}
+	if o := Versions["1.11.1"].plugins["kubernetes"].namedOptions["pods"]; o.status != "" {
+		t.Errorf("expected the pods option to be kept supported in Versions, got %v", o.status)
+	}
+	if Versions["1.11.1"].k8sReleases[0] == "0.0" {
+		t.Error("expected the Kubernetes releases to be kept in Versions")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	startCorefile := `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+	// a catalog overlay that only knows about the 1.3.x and 1.4.0 releases
+	catalog := DefaultCatalog()
+	for v := range catalog {
+		if v != "1.3.0" && v != "1.3.1" && v != "1.4.0" {
+			delete(catalog, v)
+		}
+	}
+	m := NewMigrator(catalog)
+
+	if _, err := m.Migrate("1.3.1", "1.4.0", startCorefile, false); err != nil {
+		t.Errorf("expected migration within the catalog to succeed, got: %v", err)
+	}
+	if _, err := m.Migrate("1.3.1", "1.5.0", startCorefile, false); err == nil {
+		t.Error("expected migration outside of the catalog to fail")
+	}
+	if _, err := Migrate("1.3.1", "1.5.0", startCorefile, false); err != nil {
+		t.Errorf("expected the default catalog to be unaffected by the overlay, got: %v", err)
+	}
+
+	expected := []string{"1.3.0", "1.3.1", "1.4.0"}
+	got := m.ValidVersions()
+	if len(got) != len(expected) {
+		t.Fatalf("expected versions %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected versions %v, got %v", expected, got)
+		}
+	}
+
+	notices, err := m.Deprecated("1.3.1", "1.4.0", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notices) != 2 {
+		t.Errorf("expected 2 notices, got %v", len(notices))
+	}
+}
+
+func TestCatalogSetStatus(t *testing.T) {
+	startCorefile := `.:53 {
+    kubernetes cluster.local {
+        pods insecure
+        ttl 30
+    }
+    rewrite ttl exact example.org 30
+    forward . /etc/resolv.conf
+}
+`
+	// a test catalog deprecating an option, a rewrite rule form and a plugin in 1.11.1
+	catalog := DefaultCatalog()
+	for _, s := range []Status{
+		{Plugin: "kubernetes", Option: "ttl", Severity: SevDeprecated},
+		{Plugin: "rewrite", Rule: "ttl", Severity: SevDeprecated, ReplacedBy: "answer auto"},
+		{Plugin: "forward", Severity: SevDeprecated, Additional: "Test only."},
+	} {
+		if err := catalog.SetStatus("1.11.1", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notices, err := NewMigrator(catalog).Deprecated("1.11.0", "1.11.1", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`Option "ttl" in plugin "kubernetes" is deprecated in 1.11.1.`,
+		`Rule "ttl" in plugin "rewrite" is deprecated in 1.11.1. It is replaced by "answer auto".`,
+		`Plugin "forward" is deprecated in 1.11.1. Test only.`,
+	}
+	if len(notices) != len(expected) {
+		t.Fatalf("expected %v notices, got %v", expected, notices)
+	}
+	for i, n := range notices {
+		if n.ToString() != expected[i] {
+			t.Errorf("expected notice '%v', got '%v'", expected[i], n.ToString())
+		}
+	}
+
+	if notices, err := Deprecated("1.11.0", "1.11.1", startCorefile); err != nil || len(notices) != 0 {
+		t.Errorf("expected the default catalog to be unaffected by the test catalog, got %v (%v)", notices, err)
+	}
+
+	for _, tc := range []struct {
+		version string
+		status  Status
+		err     error
+	}{
+		{version: "1.99.0", status: Status{Plugin: "forward"}, err: ErrUnknownVersion},
+		{version: "1.11.1", status: Status{Option: "ttl"}, err: ErrInvalidStatus},
+		{version: "1.11.1", status: Status{Plugin: "rewrite", Option: "ttl", Rule: "ttl"}, err: ErrInvalidStatus},
+		{version: "1.11.1", status: Status{Plugin: "forward", Rule: "ttl"}, err: ErrInvalidStatus},
+		{version: "1.11.1", status: Status{Plugin: "forward", Severity: SevNewDefault}, err: ErrInvalidStatus},
+	} {
+		if err := catalog.SetStatus(tc.version, tc.status); !errors.Is(err, tc.err) {
+			t.Errorf("expected setting %+v in %v to fail with '%v', got '%v'", tc.status, tc.version, tc.err, err)
+		}
+	}
+}
+
+func TestMigrateWithOptions(t *testing.T) {
+	testCases := []struct {
+		name             string
+		fromVersion      string
+		toVersion        string
+		options          MigrateOptions
+		startCorefile    string
+		expectedCorefile string
+		shouldErr        bool
+	}{
+		{
+			name:        "keep unknown plugin",
+			fromVersion: "1.6.6",
+			toVersion:   "1.6.7",
+			startCorefile: `.:53 {
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+}
+`,
+			expectedCorefile: `.:53 {
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+}
+`,
+		},
+		{
+			name:        "error on unknown plugin",
+			fromVersion: "1.6.6",
+			toVersion:   "1.6.7",
+			options:     MigrateOptions{UnknownPlugins: UnknownError},
+			startCorefile: `.:53 {
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+}
+`,
+			shouldErr: true,
+		},
+		{
+			name:        "error on unknown option",
+			fromVersion: "1.6.6",
+			toVersion:   "1.6.7",
+			options:     MigrateOptions{UnknownOptions: UnknownError},
+			startCorefile: `.:53 {
+    kubernetes cluster.local {
+        moo insecure
+    }
+}
+`,
+			shouldErr: true,
+		},
+		{
+			name:        "cache serve_stale option kept on error on unknown option",
+			fromVersion: "1.6.6",
+			toVersion:   "1.9.4",
+			options:     MigrateOptions{UnknownOptions: UnknownError, SkipNewDefaults: true},
+			startCorefile: `.:53 {
+    forward . /etc/resolv.conf
+    cache 30 {
+        serve_stale
+    }
+}
+`,
+			expectedCorefile: `.:53 {
+    forward . /etc/resolv.conf
+    cache 30 {
+        serve_stale
+    }
+}
+`,
+		},
+		{
+			name:        "skip new defaults",
+			fromVersion: "1.4.0",
+			toVersion:   "1.7.0",
+			options:     MigrateOptions{SkipNewDefaults: true},
+			startCorefile: `.:53 {
+    health
+    kubernetes cluster.local
+    forward . /etc/resolv.conf
+}
+`,
+			expectedCorefile: `.:53 {
+    health
+    kubernetes cluster.local
+    forward . /etc/resolv.conf
+}
+`,
+		},
+		{
+			name:        "server block split not allowed",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{NoServerBlockSplit: true},
+			startCorefile: `.:53 {
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+}
+`,
+			shouldErr: true,
+		},
+		{
+			name:        "server block split not allowed, merged into an existing server block",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{NoServerBlockSplit: true},
+			startCorefile: `.:53 {
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+}
+example.org:53 {
+    errors
+}
+`,
+			expectedCorefile: `.:53 {
+    forward . /etc/resolv.conf
+}
+
+example.org:53 {
+    errors
+    forward . 1.2.3.4
+}
+`,
+		},
+		{
+			name:        "server block split not allowed, continue on error",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{NoServerBlockSplit: true, ContinueOnError: true},
+			startCorefile: `.:53 {
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+}
+example.net:53 {
+    proxy example.net 1.2.3.4
+}
+`,
+			expectedCorefile: `.:53 {
+    forward example.org 1.2.3.4
+    forward . /etc/resolv.conf
+}
+
+example.net:53 {
+    forward . 1.2.3.4
+}
+`,
+			shouldErr: true,
+		},
+		{
+			name:        "abort on removed",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{AbortSeverity: SevRemoved},
+			startCorefile: `.:53 {
+    proxy . /etc/resolv.conf
+}
+`,
+			shouldErr: true,
+		},
+		{
+			name:        "below abort severity",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{AbortSeverity: SevUnsupported},
+			startCorefile: `.:53 {
+    proxy . /etc/resolv.conf
+}
+`,
+			expectedCorefile: `.:53 {
+    forward . /etc/resolv.conf
+}
+`,
+		},
+		{
+			name:        "invalid abort severity",
+			fromVersion: "1.3.1",
+			toVersion:   "1.5.0",
+			options:     MigrateOptions{AbortSeverity: "banana"},
+			startCorefile: `.:53 {
+    proxy . /etc/resolv.conf
+}
+`,
+			shouldErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := MigrateWithOptions(testCase.fromVersion, testCase.toVersion, testCase.startCorefile, testCase.options)
+
+			if testCase.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error, got:\n%v", result)
+				}
+				if testCase.expectedCorefile == "" {
+					return
+				}
+			} else if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if result != testCase.expectedCorefile {
+				t.Errorf("expected != result\n%v\n%v", testCase.expectedCorefile, result)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	stubDomainCorefile := `example.com:53 {
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+}
+`
+	conflictingStubDomainCorefile := `.:53 {
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+}
+
+example.org {
+    proxy . 5.6.7.8
+}
+`
+	unknownPluginCorefile := `.:53 {
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+}
+`
+	testCases := []struct {
+		name     string
+		err      func() error
+		expected error
+		location *MigrationError
+	}{
+		{
+			name:     "unknown start version",
+			err:      func() error { return ValidUpMigration("banana", "1.5.0") },
+			expected: ErrUnknownVersion,
+		},
+		{
+			name:     "unknown destination version",
+			err:      func() error { return ValidUpMigration("1.3.1", "apple") },
+			expected: ErrUnknownVersion,
+		},
+		{
+			name:     "up migration to an older version",
+			err:      func() error { return ValidUpMigration("1.5.0", "1.3.1") },
+			expected: ErrInvalidDirection,
+		},
+		{
+			name: "down migration to a newer version",
+			err: func() error {
+				_, err := MigrateDown("1.3.1", "1.5.0", unknownPluginCorefile)
+				return err
+			},
+			expected: ErrInvalidDirection,
+		},
+		{
+			name: "unknown sha",
+			err: func() error {
+				_, err := VersionFromSHA("blah")
+				return err
+			},
+			expected: ErrUnknownSHA,
+		},
+		{
+			name: "unhandled server block",
+			err: func() error {
+				_, err := Migrate("1.3.1", "1.5.0", stubDomainCorefile, true)
+				return err
+			},
+			expected: ErrUnhandledServerBlock,
+			location: &MigrationError{Version: "1.4.0", Server: "example.com:53", Plugin: "forward"},
+		},
+		{
+			name: "stub domain already forwarded",
+			err: func() error {
+				_, err := Migrate("1.3.1", "1.5.0", conflictingStubDomainCorefile, true)
+				return err
+			},
+			expected: ErrUnhandledServerBlock,
+			location: &MigrationError{Version: "1.4.0", Server: ".:53", Plugin: "forward"},
+		},
+		{
+			name: "unsupported plugin",
+			err: func() error {
+				_, err := MigrateWithOptions("1.6.6", "1.6.7", unknownPluginCorefile, MigrateOptions{UnknownPlugins: UnknownError})
+				return err
+			},
+			expected: ErrUnsupported,
+			location: &MigrationError{Version: "1.6.7", Server: ".:53", Plugin: "route53"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err()
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected error to be '%v', got '%v'", tc.expected, err)
+			}
+			if tc.location == nil {
+				return
+			}
+			var mErr *MigrationError
+			if !errors.As(err, &mErr) {
+				t.Fatalf("expected a MigrationError, got '%v'", err)
+			}
+			if mErr.Version != tc.location.Version || mErr.Server != tc.location.Server || mErr.Plugin != tc.location.Plugin {
+				t.Errorf("expected error location %+v, got %+v", tc.location, mErr)
+			}
+		})
+	}
+}
+
+func TestMigrateContinueOnError(t *testing.T) {
+	startCorefile := `.:53 {
+    errors
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    proxy example.org 1.2.3.4
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+}
+
+example.com:5353 {
+    errors
+    proxy example.net 5.6.7.8
+    proxy . /etc/resolv.conf
+}
+`
+	expectedCorefile := `.:53 {
+    errors
+    route53 example.org.:Z1Z2Z3Z4DZ5Z6Z7
+    forward . /etc/resolv.conf
+    cache 30
+    loop
+}
+
+example.com:5353 {
+    errors
+    forward example.net 5.6.7.8
+    forward . /etc/resolv.conf
+}
+
+example.org {
+    errors
+    forward . 1.2.3.4
+    cache 30
+    loop
+}
+`
+	opts := MigrateOptions{Deprecations: true, UnknownPlugins: UnknownError, ContinueOnError: true}
+	result, err := MigrateWithOptions("1.3.1", "1.4.0", startCorefile, opts)
+	if result != expectedCorefile {
+		t.Errorf("expected != result\n%v\n%v", expectedCorefile, result)
+	}
+
+	var errs MigrationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected MigrationErrors, got '%v'", err)
+	}
+	expected := []MigrationError{
+		{Version: "1.4.0", Server: ".:53", Plugin: "route53"},
+		{Version: "1.4.0", Server: "example.com:5353", Plugin: "forward"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %v errors, got %v: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i].Version != e.Version || errs[i].Server != e.Server || errs[i].Plugin != e.Plugin {
+			t.Errorf("expected error location %+v, got %+v", e, errs[i])
+		}
+	}
+	if !errors.Is(err, ErrUnsupported) || !errors.Is(err, ErrUnhandledServerBlock) {
+		t.Errorf("expected errors to match ErrUnsupported and ErrUnhandledServerBlock, got '%v'", err)
+	}
+
+	// without ContinueOnError, the first error aborts the migration
+	opts.ContinueOnError = false
+	_, err = MigrateWithOptions("1.3.1", "1.4.0", startCorefile, opts)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got '%v'", err)
+	}
+}
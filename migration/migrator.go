@@ -0,0 +1,163 @@
+package migration
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Catalog holds a map of plugin/option migrations per CoreDNS release, keyed by CoreDNS version.  The releases are
+// opaque: a catalog is built from the catalog shipped with this library (see DefaultCatalog), by removing releases
+// from it, by adding a copy of one of its releases under another version, or by changing the support status of
+// plugins, options and rewrite rule forms in its releases (see SetStatus).  Migration actions cannot be added.
+type Catalog map[string]release
+
+// Status is the support status of a plugin, or of one of its options or rewrite rule forms, in a release.
+type Status struct {
+	Plugin     string
+	Option     string   // the option of the plugin, if the status is the option's
+	Rule       string   // the form of a rewrite rule, e.g. "name regex", if the status is the rule form's
+	Severity   Severity // "" if supported, or 'deprecated', 'ignored', 'removed' or 'unsupported'
+	ReplacedBy string
+	Additional string
+}
+
+// SetStatus sets the support status of a plugin, option or rewrite rule form in the release of the catalog with the
+// given version, adding the catalog entry if the release has none, e.g. to deprecate an option in a test catalog.
+// Only the status is set: the migration actions of the entry are kept, so SevNewDefault, which needs an action adding
+// the default, cannot be set.  The status applies to this release only; set it in each release it applies to.  The
+// catalog must not be modified once it is used by a Migrator.
+func (c Catalog) SetStatus(version string, s Status) error {
+	r, ok := c[version]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownVersion, version)
+	}
+	switch {
+	case s.Plugin == "":
+		return fmt.Errorf("%w: no plugin", ErrInvalidStatus)
+	case s.Option != "" && s.Rule != "":
+		return fmt.Errorf("%w: both option '%v' and rule '%v' are set", ErrInvalidStatus, s.Option, s.Rule)
+	case s.Rule != "" && s.Plugin != "rewrite":
+		return fmt.Errorf("%w: rule '%v' is set for plugin '%v', rules are forms of the rewrite plugin", ErrInvalidStatus, s.Rule, s.Plugin)
+	}
+	switch s.Severity {
+	case "", SevDeprecated, SevIgnored, SevRemoved, SevUnsupported:
+	default:
+		return fmt.Errorf("%w: severity '%v'", ErrInvalidStatus, s.Severity)
+	}
+
+	if r.plugins == nil {
+		r.plugins = map[string]plugin{}
+	}
+	p := r.plugins[s.Plugin]
+	switch {
+	case s.Option != "":
+		if p.namedOptions == nil {
+			p.namedOptions = map[string]option{}
+		}
+		o := p.namedOptions[s.Option]
+		o.status, o.replacedBy, o.additional = s.Severity, s.ReplacedBy, s.Additional
+		p.namedOptions[s.Option] = o
+	case s.Rule != "":
+		if p.rules == nil {
+			p.rules = map[string]rule{}
+		}
+		ru := p.rules[s.Rule]
+		ru.status, ru.replacedBy, ru.additional = s.Severity, s.ReplacedBy, s.Additional
+		p.rules[s.Rule] = ru
+	default:
+		p.status, p.replacedBy, p.additional = s.Severity, s.ReplacedBy, s.Additional
+	}
+	r.plugins[s.Plugin] = p
+	c[version] = r
+	return nil
+}
+
+// DefaultCatalog returns a deep copy of the release catalog shipped with this library (see Versions). The copy can be
+// filtered or modified without affecting other users of the library.
+func DefaultCatalog() Catalog {
+	c := make(Catalog, len(Versions))
+	for v, r := range Versions {
+		c[v] = r.copy()
+	}
+	return c
+}
+
+// copy returns a deep copy of the release, sharing only its migration actions.
+func (r release) copy() release {
+	r.k8sReleases = append([]string(nil), r.k8sReleases...)
+	if r.platformDigests != nil {
+		digests := make(map[string]string, len(r.platformDigests))
+		for platform, digest := range r.platformDigests {
+			digests[platform] = digest
+		}
+		r.platformDigests = digests
+	}
+	plugins := make(map[string]plugin, len(r.plugins))
+	for name, p := range r.plugins {
+		plugins[name] = p.copy()
+	}
+	r.plugins = plugins
+	return r
+}
+
+// copy returns a deep copy of the plugin, sharing only its migration actions.
+func (p plugin) copy() plugin {
+	p.namedOptions = copyOptions(p.namedOptions)
+	p.patternOptions = copyOptions(p.patternOptions)
+	if p.rules != nil {
+		rules := make(map[string]rule, len(p.rules))
+		for form, r := range p.rules {
+			rules[form] = r
+		}
+		p.rules = rules
+	}
+	return p
+}
+
+func copyOptions(options map[string]option) map[string]option {
+	if options == nil {
+		return nil
+	}
+	c := make(map[string]option, len(options))
+	for name, o := range options {
+		c[name] = o
+	}
+	return c
+}
+
+// Migrator performs Corefile migrations against an explicit release catalog. A Migrator does not modify its catalog,
+// and is safe for concurrent use as long as the catalog it was constructed with is not modified.  Migration plans and
+// releases are compiled from the catalog on first use and cached, so a Migrator is best reused across migrations.
+// The caches are bounded by the size of the catalog: at most two plans per pair of releases, and one compiled entry
+// per release.
+type Migrator struct {
+	catalog      Catalog
+	unknownPatch UnknownPatchPolicy
+	plans        sync.Map // compiled migration plans, keyed by planKey
+	releases     sync.Map // compiled plugins of the releases, keyed by version, see compiledPlugins
+}
+
+// MigratorOption configures a Migrator.
+type MigratorOption func(*Migrator)
+
+// WithUnknownPatchPolicy sets how the Migrator resolves patch releases missing from its catalog.  The default is
+// PatchError.
+func WithUnknownPatchPolicy(policy UnknownPatchPolicy) MigratorOption {
+	return func(m *Migrator) {
+		m.unknownPatch = policy
+	}
+}
+
+// NewMigrator returns a Migrator using the given release catalog.
+func NewMigrator(catalog Catalog, opts ...MigratorOption) *Migrator {
+	m := &Migrator{catalog: catalog}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// defaultMigrator backs the package level functions.  It migrates against a copy of Versions taken when the package
+// is initialized, so changes made to Versions afterwards are not visible to the package level functions: use a
+// Migrator with a modified DefaultCatalog instead.
+var defaultMigrator = NewMigrator(DefaultCatalog())
@@ -6,7 +6,9 @@ import "fmt"
 type Notice struct {
 	Plugin     string
 	Option     string
-	Severity   string // 'deprecated', 'removed', or 'unsupported'
+	Rule       string   // the form of a rewrite rule, e.g. "name regex", see RewriteRule
+	Server     string   // the server block the plugin/option is in, e.g. ".:53"
+	Severity   Severity // 'deprecated', 'removed', or 'unsupported'
 	ReplacedBy string
 	Additional string
 	Version    string
@@ -14,17 +16,20 @@ type Notice struct {
 
 func (n *Notice) ToString() string {
 	s := ""
-	if n.Option == "" {
-		s += fmt.Sprintf(`Plugin "%v" `, n.Plugin)
-	} else {
+	switch {
+	case n.Rule != "":
+		s += fmt.Sprintf(`Rule "%v" in plugin "%v" `, n.Rule, n.Plugin)
+	case n.Option != "":
 		s += fmt.Sprintf(`Option "%v" in plugin "%v" `, n.Option, n.Plugin)
+	default:
+		s += fmt.Sprintf(`Plugin "%v" `, n.Plugin)
 	}
 	if n.Severity == SevUnsupported {
 		s += "is unsupported by this migration tool in " + n.Version + "."
 	} else if n.Severity == SevNewDefault {
 		s += "is added as a default in " + n.Version + "."
 	} else {
-		s += "is " + n.Severity + " in " + n.Version + "."
+		s += "is " + string(n.Severity) + " in " + n.Version + "."
 	}
 	if n.ReplacedBy != "" {
 		s += fmt.Sprintf(` It is replaced by "%v".`, n.ReplacedBy)
@@ -37,12 +42,14 @@ func (n *Notice) ToString() string {
 
 const (
 	// The following statuses are used to indicate the state of support/deprecation in a given release.
-	SevDeprecated  = "deprecated"  // deprecated, but still completely functional
-	SevIgnored     = "ignored"     // if included in the corefile, it will be ignored by CoreDNS
-	SevRemoved     = "removed"     // completely removed from CoreDNS, and would cause CoreDNS to exit if present in the Corefile
-	SevNewDefault  = "newdefault"  // added to the default corefile.  CoreDNS may not function properly if it is not present in the corefile.
-	SevUnsupported = "unsupported" // the plugin/option is not supported by the migration tool
-
-	// The following statuses are used for selecting/filtering notifications
-	SevAll = "all" // show all statuses
+	SevDeprecated  Severity = "deprecated"  // deprecated, but still completely functional
+	SevIgnored     Severity = "ignored"     // if included in the corefile, it will be ignored by CoreDNS
+	SevRemoved     Severity = "removed"     // completely removed from CoreDNS, and would cause CoreDNS to exit if present in the Corefile
+	SevNewDefault  Severity = "newdefault"  // added to the default corefile.  CoreDNS may not function properly if it is not present in the corefile.
+	SevUnsupported Severity = "unsupported" // the plugin/option is not supported by the migration tool
 )
+
+// SevAll is not a severity.  It was used for selecting/filtering notifications, and is kept for compatibility.
+//
+// Deprecated: use FilterNotices, NoticesWithSeverity or NoticesAtLeast to select notifications.
+const SevAll = "all"
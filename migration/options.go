@@ -0,0 +1,65 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownPolicy defines how a migration handles plugins/options that are not in the release catalog.
+type UnknownPolicy int
+
+const (
+	UnknownKeep  UnknownPolicy = iota // keep unknown plugins/options in the Corefile as they are
+	UnknownError                      // abort the migration with an error
+)
+
+// MigrateOptions controls the behavior of a migration.  The zero value migrates on a best effort basis, in the same
+// way as Migrate with deprecations set to false.
+type MigrateOptions struct {
+	// Deprecations migrates deprecated plugins/options as soon as they are deprecated.  Otherwise, deprecated
+	// plugins/options are migrated only once they become removed or ignored.
+	Deprecations bool
+
+	// UnknownPlugins and UnknownOptions define how plugins/options that are not handled by the migration tool are
+	// treated.
+	UnknownPlugins UnknownPolicy
+	UnknownOptions UnknownPolicy
+
+	// SkipNewDefaults prevents the migration from adding new default plugins/options.
+	SkipNewDefaults bool
+
+	// NoServerBlockSplit makes the migration fail instead of splitting plugins out into new server blocks.
+	NoServerBlockSplit bool
+
+	// ContinueOnError keeps migrating everything it can when an error occurs.  Server blocks/plugins that fail to
+	// migrate are left untouched, and all errors are returned together as MigrationErrors along with the partially
+	// migrated Corefile.
+	ContinueOnError bool
+
+	// AbortSeverity aborts the migration if any notice of this severity or higher would be raised.  Severities are
+	// ordered: newdefault < deprecated < ignored < removed < unsupported.  Empty disables the check.
+	AbortSeverity Severity
+}
+
+// checkSeverity returns an error if migrating the Corefile would raise any notice at or above the given severity.
+func (m *Migrator) checkSeverity(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, severity Severity) error {
+	if _, err := ParseSeverity(string(severity)); err != nil {
+		return err
+	}
+	notices, err := m.getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, false)
+	if err != nil {
+		return err
+	}
+	unsupported, err := m.getStatus(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, true)
+	if err != nil {
+		return err
+	}
+	var msgs []string
+	for _, n := range NoticesAtLeast(append(notices, unsupported...), severity) {
+		msgs = append(msgs, n.ToString())
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("%w on %v notices: %v", ErrAbortSeverity, severity, strings.Join(msgs, " "))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// MigrationPlan is a preview of a migration, step by step through each intermediate release.
+type MigrationPlan struct {
+	From  string // the CoreDNS version migrated from
+	To    string // the CoreDNS version migrated to
+	Input string // the Corefile migrated from, as given
+	Steps []PlanStep
+}
+
+// PlanStep is a single step of a MigrationPlan, migrating the Corefile to the next release.
+type PlanStep struct {
+	From        string          // the CoreDNS version migrated from in this step
+	Version     string          // the CoreDNS version migrated to in this step
+	Corefile    string          // the Corefile after this step
+	Changed     bool            // true if this step changes the Corefile
+	Notices     []Notice        // the notices raised by this step
+	PreProcess  bool            // true if this release defines a corefile-wide rewrite, applied before migrating plugins
+	PostProcess bool            // true if this release defines a corefile-wide rewrite, applied after migrating plugins
+	Errors      MigrationErrors // the errors collected by this step, if MigrateOptions.ContinueOnError is set
+}
+
+// Corefile returns the Corefile at the end of the plan, which is the input Corefile if the plan has no steps.
+func (p *MigrationPlan) Corefile() string {
+	if len(p.Steps) == 0 {
+		return p.Input
+	}
+	return p.Steps[len(p.Steps)-1].Corefile
+}
+
+// Plan returns a step by step preview of migrating the Corefile from fromCoreDNSVersion to toCoreDNSVersion.
+// See Migrator.PlanWithOptions.
+func Plan(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (*MigrationPlan, error) {
+	return defaultMigrator.Plan(fromCoreDNSVersion, toCoreDNSVersion, corefileStr)
+}
+
+// Plan returns a step by step preview of migrating the Corefile, using the Migrator's catalog.
+func (m *Migrator) Plan(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string) (*MigrationPlan, error) {
+	return m.PlanWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, MigrateOptions{})
+}
+
+// PlanWithOptions returns a step by step preview of migrating the Corefile from fromCoreDNSVersion to toCoreDNSVersion.
+// See Migrator.PlanWithOptions.
+func PlanWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, opts MigrateOptions) (*MigrationPlan, error) {
+	return defaultMigrator.PlanWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr, opts)
+}
+
+// PlanWithOptions returns a step by step preview of migrating the Corefile, using the Migrator's catalog.  Each step
+// holds the Corefile as it would be after migrating to that release, so any step can be used as a stopping point for
+// a staged rollout.  The plan follows the same rules as MigrateWithOptions, except that opts.AbortSeverity is ignored,
+// since the notices are part of the plan.
+func (m *Migrator) PlanWithOptions(fromCoreDNSVersion, toCoreDNSVersion, corefileStr string, opts MigrateOptions) (*MigrationPlan, error) {
+	fromCoreDNSVersion, toCoreDNSVersion, err := m.resolveVersions(fromCoreDNSVersion, toCoreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
+	plan := &MigrationPlan{From: fromCoreDNSVersion, To: toCoreDNSVersion, Input: corefileStr}
+	if fromCoreDNSVersion == toCoreDNSVersion {
+		return plan, nil
+	}
+	opts.AbortSeverity = ""
+	mp, err := m.plan(fromCoreDNSVersion, toCoreDNSVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+	cf, err := corefile.New(corefileStr)
+	if err != nil {
+		return nil, err
+	}
+	prev := fromCoreDNSVersion
+	cfStr := cf.ToString()
+	for _, step := range mp.steps {
+		v := step.version
+		notices, err := m.getStatus(prev, v, cfStr, false)
+		if err != nil {
+			return nil, err
+		}
+		var errs MigrationErrors
+		cf, err = m.migrateStep(cf, step, opts, &errs)
+		if err != nil {
+			return nil, err
+		}
+		newCfStr := cf.ToString()
+		plan.Steps = append(plan.Steps, PlanStep{
+			From:        prev,
+			Version:     v,
+			Corefile:    newCfStr,
+			Changed:     newCfStr != cfStr,
+			Notices:     notices,
+			PreProcess:  step.release.preProcess != nil,
+			PostProcess: step.release.postProcess != nil,
+			Errors:      errs,
+		})
+		prev, cfStr = v, newCfStr
+	}
+	return plan, nil
+}
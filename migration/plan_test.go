@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	startCorefile := `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+        pods insecure
+        upstream
+        fallthrough in-addr.arpa ip6.arpa
+        transfer to 10.1.1.1
+    }
+    prometheus :9153
+    proxy . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`
+	plan, err := Plan("1.2.6", "1.8.0", startCorefile)
+	if err != nil {
+		t.Fatalf("expected plan to succeed, got '%v'", err)
+	}
+	if plan.From != "1.2.6" || plan.To != "1.8.0" {
+		t.Errorf("expected plan from 1.2.6 to 1.8.0, got %v to %v", plan.From, plan.To)
+	}
+
+	migrated, err := Migrate("1.2.6", "1.8.0", startCorefile, false)
+	if err != nil {
+		t.Fatalf("expected migration to succeed, got '%v'", err)
+	}
+	if plan.Corefile() != migrated {
+		t.Errorf("expected plan to end with the migrated Corefile.\nExpected:\n%v\nGot:\n%v", migrated, plan.Corefile())
+	}
+
+	steps := map[string]PlanStep{}
+	prev := "1.2.6"
+	for _, s := range plan.Steps {
+		if s.From != prev {
+			t.Errorf("expected step to %v to start from %v, got %v", s.Version, prev, s.From)
+		}
+		prev = s.Version
+		steps[s.Version] = s
+	}
+	if prev != "1.8.0" || len(steps) != 18 {
+		t.Errorf("expected 18 steps ending at 1.8.0, got %v ending at %v", len(steps), prev)
+	}
+
+	if s := steps["1.4.0"]; !s.PostProcess || s.PreProcess || s.Changed || len(s.Notices) != 2 {
+		t.Errorf("unexpected step to 1.4.0: %+v", s)
+	}
+	if s := steps["1.5.0"]; !s.Changed || len(s.Notices) != 3 {
+		t.Errorf("unexpected step to 1.5.0: %+v", s)
+	}
+	if s := steps["1.8.0"]; !s.PreProcess || s.PostProcess || !s.Changed {
+		t.Errorf("unexpected step to 1.8.0: %+v", s)
+	}
+	if s := steps["1.6.0"]; s.Changed || len(s.Notices) != 0 {
+		t.Errorf("unexpected step to 1.6.0: %+v", s)
+	}
+
+	plan, err = Plan("1.8.0", "v1.8.0", startCorefile)
+	if err != nil || len(plan.Steps) != 0 {
+		t.Errorf("expected an empty plan, got %+v (%v)", plan, err)
+	}
+	if plan != nil && plan.Corefile() != startCorefile {
+		t.Errorf("expected an empty plan to end with the input Corefile.\nExpected:\n%v\nGot:\n%v", startCorefile, plan.Corefile())
+	}
+
+	_, err = Plan("1.8.0", "1.2.6", startCorefile)
+	if !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got '%v'", err)
+	}
+}
@@ -2,24 +2,28 @@ package migration
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/coredns/corefile-migration/migration/corefile"
 )
 
 type plugin struct {
-	status         string
+	status         Severity
 	replacedBy     string
 	additional     string
 	namedOptions   map[string]option
 	patternOptions map[string]option
-	action         pluginActionFn // action affecting this plugin only
-	add            serverActionFn // action to add a new plugin to the server block
-	downAction     pluginActionFn // downgrade action affecting this plugin only
+	rules          map[string]rule // rule forms of the rewrite plugin, e.g. "name regex" or "answer name", see RewriteRule
+	inlineEntries  bool            // other options are inline entries of the hosts plugin, see HostsEntry
+	action         pluginActionFn  // action affecting this plugin only
+	add            serverActionFn  // action to add a new plugin to the server block
+	downAction     pluginActionFn  // downgrade action affecting this plugin only
 }
 
 type option struct {
 	name       string
-	status     string
+	status     Severity
 	replacedBy string
 	additional string
 	action     optionActionFn // action affecting this option only
@@ -27,10 +31,19 @@ type option struct {
 	downAction optionActionFn // downgrade action affecting this option only
 }
 
-type corefileAction func(*corefile.Corefile) (*corefile.Corefile, error)
+type rule struct {
+	status     Severity
+	replacedBy string
+	additional string
+	action     ruleActionFn // action affecting rules of this form only
+	downAction ruleActionFn // downgrade action affecting rules of this form only
+}
+
+type corefileAction func(*corefile.Corefile, MigrateOptions) (*corefile.Corefile, error)
 type serverActionFn func(*corefile.Server) (*corefile.Server, error)
 type pluginActionFn func(*corefile.Plugin) (*corefile.Plugin, error)
 type optionActionFn func(*corefile.Option) (*corefile.Option, error)
+type ruleActionFn func(*RewriteRule) (*RewriteRule, error)
 
 // plugins holds a map of plugin names and their migration rules per "version".  "Version" here is meaningless outside
 // of the context of this code. Each change in options or migration actions for a plugin requires a new "version"
@@ -266,7 +279,7 @@ var plugins = map[string]map[string]plugin{
 				"lameduck": {
 					status: SevNewDefault,
 					add: func(c *corefile.Plugin) (*corefile.Plugin, error) {
-						return addOptionToPlugin(c, &corefile.Option{Name: "lameduck 5s"})
+						return addOptionToPlugin(c, &corefile.Option{Name: "lameduck", Args: []string{"5s"}})
 					},
 					downAction: removeOption,
 				},
@@ -282,16 +295,13 @@ var plugins = map[string]map[string]plugin{
 				"reload":      {},
 				"fallthrough": {},
 			},
-			patternOptions: map[string]option{
-				`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}`:              {}, // close enough
-				`[0-9A-Fa-f]{1,4}:[:0-9A-Fa-f]+:[0-9A-Fa-f]{1,4}`: {}, // less close, but still close enough
-			},
+			inlineEntries: true,
 		},
 	},
 
 	"rewrite": {
 		"v1": plugin{
-			namedOptions: map[string]option{
+			rules: map[string]rule{
 				"type":        {},
 				"class":       {},
 				"name":        {},
@@ -300,24 +310,52 @@ var plugins = map[string]map[string]plugin{
 			},
 		},
 		"v2": plugin{
-			namedOptions: map[string]option{
+			rules: map[string]rule{
 				"type":        {},
 				"class":       {},
 				"name":        {},
 				"answer name": {},
 				"edns0":       {},
-				"ttl":         {}, // new option
+				"ttl":         {}, // new rule
 			},
 		},
 		"v3": plugin{
-			namedOptions: map[string]option{
+			rules: map[string]rule{
+				"type":         {},
+				"class":        {},
+				"name":         {},
+				"answer name":  {},
+				"answer value": {}, // new response rewrite
+				"answer auto":  {}, // new response rewrite
+				"edns0":        {},
+				"ttl":          {},
+			},
+		},
+		"v4": plugin{
+			rules: map[string]rule{
+				"type":         {},
+				"class":        {},
+				"name":         {},
+				"answer name":  {},
+				"answer value": {},
+				"answer auto":  {},
+				"edns0":        {},
+				"ttl":          {},
+				"cname_target": {}, // new rule
+			},
+		},
+		"v5": plugin{
+			rules: map[string]rule{
 				"type":         {},
 				"class":        {},
 				"name":         {},
 				"answer name":  {},
+				"answer value": {},
+				"answer auto":  {},
 				"edns0":        {},
 				"ttl":          {},
-				"cname_target": {}, // new option
+				"cname_target": {},
+				"rcode":        {}, // new rule
 			},
 		},
 	},
@@ -423,7 +461,7 @@ var plugins = map[string]map[string]plugin{
 				"max_concurrent": { // new option
 					status: SevNewDefault,
 					add: func(c *corefile.Plugin) (*corefile.Plugin, error) {
-						return addOptionToPlugin(c, &corefile.Option{Name: "max_concurrent 1000"})
+						return addOptionToPlugin(c, &corefile.Option{Name: "max_concurrent", Args: []string{"1000"}})
 					},
 					downAction: removeOption,
 				},
@@ -542,22 +580,20 @@ func renamePlugin(p *corefile.Plugin, to string) (*corefile.Plugin, error) {
 func addToServerBlockWithPlugins(sb *corefile.Server, newPlugin *corefile.Plugin, with []string) (*corefile.Server, error) {
 	if len(with) == 0 {
 		// add to all blocks
-		sb.Plugins = append(sb.Plugins, newPlugin)
+		sb.AddPlugin(newPlugin)
 		return sb, nil
 	}
-	for _, p := range sb.Plugins {
-		for _, w := range with {
-			if w == p.Name {
-				// add to this block
-				sb.Plugins = append(sb.Plugins, newPlugin)
-				return sb, nil
-			}
+	for _, w := range with {
+		if sb.FindPlugin(w) != nil {
+			// add to this block
+			sb.AddPlugin(newPlugin)
+			return sb, nil
 		}
 	}
 	return sb, nil
 }
 
-func copyKubernetesTransferOptToPlugin(cf *corefile.Corefile) (*corefile.Corefile, error) {
+func copyKubernetesTransferOptToPlugin(cf *corefile.Corefile, _ MigrateOptions) (*corefile.Corefile, error) {
 	for _, s := range cf.Servers {
 		var (
 			to   []string
@@ -600,7 +636,7 @@ func addToAllServerBlocks(sb *corefile.Server, newPlugin *corefile.Plugin) (*cor
 }
 
 func addOptionToPlugin(pl *corefile.Plugin, newOption *corefile.Option) (*corefile.Plugin, error) {
-	pl.Options = append(pl.Options, newOption)
+	pl.AddOption(newOption)
 	return pl, nil
 }
 
@@ -650,42 +686,188 @@ var proxyRemoveHttpsGoogleProtocol = func(o *corefile.Option) (*corefile.Option,
 	return o, nil
 }
 
-func breakForwardStubDomainsIntoServerBlocks(cf *corefile.Corefile) (*corefile.Corefile, error) {
-	for _, sb := range cf.Servers {
-		for j, fwd := range sb.Plugins {
-			if fwd.Name != "forward" {
-				continue
-			}
-			if len(fwd.Args) == 0 {
-				return nil, errors.New("found invalid forward plugin declaration")
-			}
-			if fwd.Args[0] == "." {
+// stubDomainSiblings are the plugins copied from a server block into the server blocks its forward stub domains are
+// broken out into.
+var stubDomainSiblings = map[string]bool{
+	"bind":   true,
+	"debug":  true,
+	"errors": true,
+	"log":    true,
+	"cache":  true,
+	"loop":   true,
+}
+
+// breakForwardStubDomainsIntoServerBlocks moves each forward plugin for a zone other than "." into a server block for
+// that zone, on the same port(s) as its server block.  The forward plugin is merged into an existing server block for
+// the zone if there is one, otherwise a new server block is added.  Either way, the sibling plugins of the forward
+// plugin listed in stubDomainSiblings come along, unless the server block already has them.  Adding a new server block fails with ErrServerBlockSplit if opts.NoServerBlockSplit
+// is set.
+func breakForwardStubDomainsIntoServerBlocks(cf *corefile.Corefile, opts MigrateOptions) (*corefile.Corefile, error) {
+	var errs MigrationErrors
+	servers := cf.Servers
+	for _, sb := range servers {
+		// check the server block can be handled before changing it, so failed server blocks are left untouched
+		if err := checkForwardStubDomainsServerBlock(cf, sb, opts); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugins := []*corefile.Plugin{}
+		for _, fwd := range sb.Plugins {
+			if fwd.Name != "forward" || fwd.Args[0] == "." {
 				// dont move the default upstream
+				plugins = append(plugins, fwd)
 				continue
 			}
-			if len(sb.DomPorts) != 1 {
-				return cf, errors.New("unhandled migration of multi-domain/port server block")
+			// the server blocks the forward plugin moves to, each once even if it serves the zone on several ports
+			targets := []*corefile.Server{}
+			newSb := &corefile.Server{}
+			for _, dp := range stubDomainDomPorts(sb, fwd.Args[0]) {
+				existing := findServerBlock(cf.Servers, dp)
+				if existing == nil {
+					newSb.DomPorts = append(newSb.DomPorts, dp)
+					continue
+				}
+				if !containsServer(targets, existing) {
+					targets = append(targets, existing)
+				}
+			}
+			for _, target := range targets {
+				if target == sb {
+					// the server block serves the zone itself
+					moved := fwd.Clone()
+					moved.Args[0] = "." // the plugin's zone changes to "." for brevity
+					plugins = append(plugins, moved)
+					continue
+				}
+				target.Plugins = append(target.Plugins, stubDomainPlugins(sb, fwd, target)...)
 			}
-			if sb.DomPorts[0] != "." && sb.DomPorts[0] != ".:53" {
-				return cf, errors.New("unhandled migration of non-default domain/port server block")
+			if len(newSb.DomPorts) > 0 {
+				newSb.Plugins = stubDomainPlugins(sb, fwd, newSb)
+				cf.Servers = append(cf.Servers, newSb)
 			}
+		}
+		sb.Plugins = plugins
+	}
+	if len(errs) > 0 {
+		return cf, errs
+	}
+	return cf, nil
+}
 
-			newSb := &corefile.Server{}                // create a new server block
-			newSb.DomPorts = []string{fwd.Args[0]}     // copy the forward zone to the server block domain
-			fwd.Args[0] = "."                          // the plugin's zone changes to "." for brevity
-			newSb.Plugins = append(newSb.Plugins, fwd) // add the plugin to its new server block
+// stubDomainPlugins returns the plugins to add to the target server block for the forward stub domain plugin fwd of
+// the server block sb: fwd for the zone "." and its siblings listed in stubDomainSiblings, in their original order.
+// Siblings already in the target server block are not added again.
+func stubDomainPlugins(sb *corefile.Server, fwd *corefile.Plugin, target *corefile.Server) []*corefile.Plugin {
+	plugins := []*corefile.Plugin{}
+	for _, p := range sb.Plugins {
+		switch {
+		case p == fwd:
+			moved := fwd.Clone()
+			moved.Args[0] = "." // the plugin's zone changes to "." for brevity
+			plugins = append(plugins, moved)
+		case stubDomainSiblings[p.Name] && !hasPlugin(target, p.Name):
+			plugins = append(plugins, p.Clone())
+		}
+	}
+	return plugins
+}
+
+func hasPlugin(s *corefile.Server, name string) bool {
+	for _, p := range s.Plugins {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsServer(servers []*corefile.Server, s *corefile.Server) bool {
+	for _, srv := range servers {
+		if srv == s {
+			return true
+		}
+	}
+	return false
+}
+
+// checkForwardStubDomainsServerBlock returns an error if the forward stub domains of the server block cannot be broken
+// out into their own server blocks, or if they would be broken out into new server blocks while opts.NoServerBlockSplit
+// is set.
+func checkForwardStubDomainsServerBlock(cf *corefile.Corefile, sb *corefile.Server, opts MigrateOptions) *MigrationError {
+	for _, fwd := range sb.Plugins {
+		if fwd.Name != "forward" {
+			continue
+		}
+		if len(fwd.Args) == 0 {
+			return &MigrationError{Server: sb.Name(), Plugin: fwd.Name, Err: errors.New("found invalid forward plugin declaration")}
+		}
+		if fwd.Args[0] == "." {
+			continue
+		}
+		dps := stubDomainDomPorts(sb, fwd.Args[0])
+		if len(dps) == 0 {
+			return &MigrationError{Server: sb.Name(), Plugin: fwd.Name, Err: fmt.Errorf("%w with forward zone %q outside of its zones", ErrUnhandledServerBlock, fwd.Args[0])}
+		}
+		for _, dp := range dps {
+			existing := findServerBlock(cf.Servers, dp)
+			if existing == nil {
+				if opts.NoServerBlockSplit {
+					return &MigrationError{Server: sb.Name(), Plugin: fwd.Name, Err: fmt.Errorf("%w: forward zone %q has no server block", ErrServerBlockSplit, fwd.Args[0])}
+				}
+				continue
+			}
+			for _, p := range existing.Plugins {
+				if p != fwd && p.Name == "forward" && len(p.Args) > 0 && (p.Args[0] == "." || sameZone(p.Args[0], fwd.Args[0])) {
+					return &MigrationError{Server: sb.Name(), Plugin: fwd.Name, Err: fmt.Errorf("%w with forward zone %q already forwarded in server block %q", ErrUnhandledServerBlock, fwd.Args[0], existing.Name())}
+				}
+			}
+		}
+	}
+	return nil
+}
 
-			// Add appropriate addtl plugins to new server block
-			newSb.Plugins = append(newSb.Plugins, &corefile.Plugin{Name: "loop"})
-			newSb.Plugins = append(newSb.Plugins, &corefile.Plugin{Name: "errors"})
-			newSb.Plugins = append(newSb.Plugins, &corefile.Plugin{Name: "cache", Args: []string{"30"}})
+func sameZone(a, b string) bool {
+	return corefile.NormalizeZone(a) == corefile.NormalizeZone(b)
+}
 
-			//add new server block to corefile
-			cf.Servers = append(cf.Servers, newSb)
+// stubDomainDomPorts returns the keys of the server block(s) serving the stub domain zone for the server block, i.e.
+// the zone with the transport and port of each of the server block's keys serving the zone.  The default port of the
+// transport is left out.
+func stubDomainDomPorts(sb *corefile.Server, zone string) []string {
+	dps := []string{}
+	seen := map[corefile.ServerAddr]bool{}
+	z := corefile.NormalizeZone(zone)
+	for _, dp := range sb.DomPorts {
+		sa := corefile.ParseServerAddr(dp)
+		if sa.Zone != "." && sa.Zone != z && !strings.HasSuffix(z, "."+sa.Zone) {
+			continue
+		}
+		key := corefile.ServerAddr{Scheme: sa.Scheme, Zone: z, Port: sa.Port}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		newDp := zone
+		if scheme, _, _ := corefile.SplitServerKey(dp); scheme != "" {
+			newDp = scheme + "://" + newDp
+		}
+		if sa.Port != corefile.DefaultPort(sa.Scheme) {
+			newDp += ":" + sa.Port
+		}
+		dps = append(dps, newDp)
+	}
+	return dps
+}
 
-			//remove the forward plugin from the original server block
-			sb.Plugins = append(sb.Plugins[:j], sb.Plugins[j+1:]...)
+// findServerBlock returns the server block serving the given key, or nil if there is none.
+func findServerBlock(servers []*corefile.Server, dp string) *corefile.Server {
+	sa := corefile.ParseServerAddr(dp)
+	for _, s := range servers {
+		for _, sdp := range s.DomPorts {
+			if corefile.ParseServerAddr(sdp) == sa {
+				return s
+			}
 		}
 	}
-	return cf, nil
+	return nil
 }
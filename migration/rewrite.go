@@ -0,0 +1,250 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// RewriteRule is the rule of a rewrite plugin, given either on one line, e.g.
+//
+//	rewrite stop name regex (.*)\.example\.org {1}.cluster.local answer name (.*)\.cluster\.local {1}.example.org
+//
+// or in a block, with the rule on the first line and its response rewrites on the next lines:
+//
+//	rewrite stop {
+//	    name regex (.*)\.example\.org {1}.cluster.local
+//	    answer name (.*)\.cluster\.local {1}.example.org
+//	}
+type RewriteRule struct {
+	Flow      string          // "continue" or "stop", if set
+	Field     string          // the part of the query rewritten, e.g. "name", "type", "edns0" or "ttl"
+	MatchType string          // how the field is matched: "exact", "prefix", "suffix", "substring" or "regex", if set
+	Args      []string        // the remaining arguments of the rule, e.g. the from and to values
+	Answers   []RewriteAnswer // the response rewrites of the rule
+	Block     bool            // true if the rule is given in a block
+}
+
+// RewriteAnswer is a response rewrite of a rewrite rule, e.g. "answer name FROM TO".
+type RewriteAnswer struct {
+	Field string   // the part of the response rewritten: "name", "value" or "auto"
+	Args  []string // the from and to values
+}
+
+// rewriteMatchFields are the rule fields taking a match type.
+var rewriteMatchFields = map[string]bool{"name": true, "ttl": true, "cname_target": true, "rcode": true}
+
+var rewriteMatchTypes = map[string]bool{"exact": true, "prefix": true, "suffix": true, "substring": true, "regex": true}
+
+// ParseRewriteRule returns the rule of a rewrite plugin.
+func ParseRewriteRule(p *corefile.Plugin) (*RewriteRule, error) {
+	r := &RewriteRule{}
+	tokens := p.Args
+	if len(tokens) > 0 && (tokens[0] == "continue" || tokens[0] == "stop") {
+		r.Flow, tokens = tokens[0], tokens[1:]
+	}
+	if len(tokens) == 0 {
+		// the rule is in a block
+		if len(p.Options) == 0 {
+			return nil, errors.New("no rewrite rule")
+		}
+		r.Block = true
+		tokens = append([]string{p.Options[0].Name}, p.Options[0].Args...)
+		for _, o := range p.Options[1:] {
+			if o.Name != "answer" || len(o.Args) == 0 {
+				return nil, fmt.Errorf("unexpected '%v' in rewrite rule block", o.Name)
+			}
+			r.Answers = append(r.Answers, RewriteAnswer{Field: o.Args[0], Args: o.Args[1:]})
+		}
+	} else if len(p.Options) > 0 {
+		return nil, errors.New("rewrite rule given both on one line and in a block")
+	}
+	r.Field, tokens = tokens[0], tokens[1:]
+	if rewriteMatchFields[r.Field] && len(tokens) > 0 && rewriteMatchTypes[tokens[0]] {
+		r.MatchType, tokens = tokens[0], tokens[1:]
+	}
+	// split off the response rewrites given on the rule's line, from the last one
+	for i := lastIndex(tokens, "answer"); i >= 0 && i < len(tokens)-1; i = lastIndex(tokens, "answer") {
+		r.Answers = append([]RewriteAnswer{{Field: tokens[i+1], Args: tokens[i+2:]}}, r.Answers...)
+		tokens = tokens[:i]
+	}
+	r.Args = tokens
+	return r, nil
+}
+
+// Plugin returns the rewrite plugin with the rule, given on one line or in a block as the rule was parsed.  Comments
+// are copied from the plugin the rule was parsed from, if given.
+func (r *RewriteRule) Plugin(from *corefile.Plugin) *corefile.Plugin {
+	p := &corefile.Plugin{Name: "rewrite"}
+	if from != nil {
+		p.Name, p.Comments, p.Comment, p.EndComments, p.EndComment = from.Name, from.Comments, from.Comment, from.EndComments, from.EndComment
+	}
+	if r.Flow != "" {
+		p.Args = append(p.Args, r.Flow)
+	}
+	rule := []string{r.Field}
+	if r.MatchType != "" {
+		rule = append(rule, r.MatchType)
+	}
+	rule = append(rule, r.Args...)
+	if !r.Block {
+		p.Args = append(p.Args, rule...)
+		for _, a := range r.Answers {
+			p.Args = append(append(p.Args, "answer", a.Field), a.Args...)
+		}
+		return p
+	}
+	p.Options = append(p.Options, &corefile.Option{Name: rule[0], Args: rule[1:]})
+	for _, a := range r.Answers {
+		p.Options = append(p.Options, &corefile.Option{Name: "answer", Args: append([]string{a.Field}, a.Args...)})
+	}
+	if from != nil && len(from.Options) == len(p.Options) {
+		for i, o := range from.Options {
+			p.Options[i].Comments, p.Options[i].Comment = o.Comments, o.Comment
+		}
+	}
+	return p
+}
+
+// forms returns the catalog forms of the rule, most specific first, e.g. "name regex" then "name".
+func (r *RewriteRule) forms() []string {
+	if r.MatchType == "" {
+		return []string{r.Field}
+	}
+	return []string{r.Field + " " + r.MatchType, r.Field}
+}
+
+// matchRule returns the catalog forms of the rule and its response rewrites listed in the plugin's rules, and the
+// forms missing from them.  Response rewrites have the form "answer FIELD", e.g. "answer name".
+func matchRule(r *RewriteRule, p plugin) (found []string, missing []string) {
+	forms := r.forms()
+	matched := false
+	for _, f := range forms {
+		if _, ok := p.rules[f]; ok {
+			found, matched = append(found, f), true
+			break
+		}
+	}
+	if !matched {
+		missing = append(missing, forms[0])
+	}
+	for _, a := range r.Answers {
+		f := "answer " + a.Field
+		if _, ok := p.rules[f]; ok {
+			found = append(found, f)
+		} else {
+			missing = append(missing, f)
+		}
+	}
+	return found, missing
+}
+
+func lastIndex(strs []string, s string) int {
+	for i := len(strs) - 1; i >= 0; i-- {
+		if strs[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleNotices returns the notices of the rule of the plugin in version v, as getStatus does for options.
+func ruleNotices(p *corefile.Plugin, vp plugin, server, v string, unsupported bool) []Notice {
+	notices := []Notice{}
+	r, err := ParseRewriteRule(p)
+	if err != nil {
+		if unsupported {
+			notices = append(notices, Notice{Plugin: p.Name, Server: server, Severity: SevUnsupported, Version: v, Additional: err.Error()})
+		}
+		return notices
+	}
+	found, missing := matchRule(r, vp)
+	if unsupported {
+		for _, form := range missing {
+			notices = append(notices, Notice{Plugin: p.Name, Rule: form, Server: server, Severity: SevUnsupported, Version: v})
+		}
+		return notices
+	}
+	for _, form := range found {
+		vr := vp.rules[form]
+		if vr.status != "" && vr.status != SevNewDefault {
+			notices = append(notices, Notice{
+				Plugin:     p.Name,
+				Rule:       form,
+				Server:     server,
+				Severity:   vr.status,
+				Version:    v,
+				ReplacedBy: vr.replacedBy,
+				Additional: vr.additional,
+			})
+		}
+	}
+	return notices
+}
+
+// migrateRule returns the plugin with its rule migrated by the actions of the rule forms it matches, or nil if the
+// plugin is removed.  Rules that cannot be parsed, or forms missing from the catalog, are handled as unknown options.
+func migrateRule(s *corefile.Server, p *corefile.Plugin, vp plugin, opts MigrateOptions) (*corefile.Plugin, error) {
+	r, err := ParseRewriteRule(p)
+	if err != nil {
+		if opts.UnknownOptions == UnknownError {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Err: fmt.Errorf("%w: %v", ErrUnsupported, err)}
+		}
+		return p, nil
+	}
+	found, missing := matchRule(r, vp)
+	if len(missing) > 0 && opts.UnknownOptions == UnknownError {
+		return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Rule: missing[0], Err: ErrUnsupported}
+	}
+	changed := false
+	for _, form := range found {
+		vr := vp.rules[form]
+		if vr.action == nil || (!opts.Deprecations && vr.status == SevDeprecated) {
+			continue
+		}
+		r, err = vr.action(r)
+		if err != nil {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Rule: form, Err: err}
+		}
+		if r == nil {
+			// remove plugin
+			return nil, nil
+		}
+		changed = true
+	}
+	if !changed {
+		return p, nil
+	}
+	return r.Plugin(p), nil
+}
+
+// migrateRuleDown returns the plugin with its rule migrated by the down actions of the rule forms it matches, or nil
+// if the plugin is removed.  Rules that cannot be parsed are left untouched.
+func migrateRuleDown(s *corefile.Server, p *corefile.Plugin, vp plugin) (*corefile.Plugin, error) {
+	r, err := ParseRewriteRule(p)
+	if err != nil {
+		return p, nil
+	}
+	found, _ := matchRule(r, vp)
+	changed := false
+	for _, form := range found {
+		vr := vp.rules[form]
+		if vr.downAction == nil {
+			continue
+		}
+		r, err = vr.downAction(r)
+		if err != nil {
+			return nil, &MigrationError{Server: s.Name(), Plugin: p.Name, Rule: form, Err: err}
+		}
+		if r == nil {
+			// remove plugin
+			return nil, nil
+		}
+		changed = true
+	}
+	if !changed {
+		return p, nil
+	}
+	return r.Plugin(p), nil
+}
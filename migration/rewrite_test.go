@@ -0,0 +1,256 @@
+package migration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+func TestParseRewriteRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   string
+		expected *RewriteRule
+		err      bool
+	}{
+		{
+			name:     "single line",
+			plugin:   `rewrite name exact a.example.org b.example.org`,
+			expected: &RewriteRule{Field: "name", MatchType: "exact", Args: []string{"a.example.org", "b.example.org"}},
+		},
+		{
+			name:     "no match type",
+			plugin:   `rewrite continue type ANY HINFO`,
+			expected: &RewriteRule{Flow: "continue", Field: "type", Args: []string{"ANY", "HINFO"}},
+		},
+		{
+			name:   "single line with answers",
+			plugin: `rewrite stop name regex (.*)\.example\.org {1}.cluster.local answer name (.*)\.cluster\.local {1}.example.org answer value (.*)\.cluster\.local {1}.example.org`,
+			expected: &RewriteRule{
+				Flow:      "stop",
+				Field:     "name",
+				MatchType: "regex",
+				Args:      []string{`(.*)\.example\.org`, "{1}.cluster.local"},
+				Answers: []RewriteAnswer{
+					{Field: "name", Args: []string{`(.*)\.cluster\.local`, "{1}.example.org"}},
+					{Field: "value", Args: []string{`(.*)\.cluster\.local`, "{1}.example.org"}},
+				},
+			},
+		},
+		{
+			name: "block",
+			plugin: `rewrite stop {
+        name regex (.*)\.example\.org {1}.cluster.local
+        answer name (.*)\.cluster\.local {1}.example.org
+    }`,
+			expected: &RewriteRule{
+				Flow:      "stop",
+				Field:     "name",
+				MatchType: "regex",
+				Args:      []string{`(.*)\.example\.org`, "{1}.cluster.local"},
+				Answers:   []RewriteAnswer{{Field: "name", Args: []string{`(.*)\.cluster\.local`, "{1}.example.org"}}},
+				Block:     true,
+			},
+		},
+		{
+			name:   "no rule",
+			plugin: `rewrite stop`,
+			err:    true,
+		},
+		{
+			name: "both forms",
+			plugin: `rewrite name a b {
+        answer name b a
+    }`,
+			err: true,
+		},
+		{
+			name: "unexpected line in block",
+			plugin: `rewrite {
+        name a b
+        type ANY HINFO
+    }`,
+			err: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := parsePlugin(t, test.plugin)
+			r, err := ParseRewriteRule(p)
+			if test.err {
+				if err == nil {
+					t.Errorf("Expected an error, got rule %+v", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(r, test.expected) {
+				t.Errorf("Expected rule %+v, got %+v", test.expected, r)
+			}
+			if got := r.Plugin(p).ToString(); got != test.plugin {
+				t.Errorf("Expected plugin:\n%v\nGot:\n%v", test.plugin, got)
+			}
+		})
+	}
+}
+
+// rewriteCatalog returns the default catalog, with the "answer name" rule form of the rewrite plugin deprecated in
+// 1.11.1 in favor of "answer auto", and the "class" rule form removed.
+func rewriteCatalog() Catalog {
+	catalog := DefaultCatalog()
+	rw := catalog["1.11.1"].plugins["rewrite"]
+	rw.rules["answer name"] = rule{
+		status:     SevDeprecated,
+		replacedBy: "answer auto",
+		action: func(r *RewriteRule) (*RewriteRule, error) {
+			r.Answers = []RewriteAnswer{{Field: "auto"}}
+			return r, nil
+		},
+	}
+	rw.rules["answer auto"] = rule{}
+	rw.rules["class"] = rule{status: SevRemoved, action: func(*RewriteRule) (*RewriteRule, error) { return nil, nil }}
+	catalog["1.11.1"].plugins["rewrite"] = rw
+	return catalog
+}
+
+func TestRewriteRules(t *testing.T) {
+	startCorefile := `.:53 {
+    rewrite name regex (.*)\.example\.org {1}.cluster.local answer name (.*)\.cluster\.local {1}.example.org
+    rewrite stop {
+        name suffix .example.org .cluster.local
+        answer name (.*)\.cluster\.local {1}.example.org
+    }
+    rewrite class CH IN
+    rewrite rcode exact SERVFAIL NXDOMAIN
+    forward . /etc/resolv.conf
+}
+`
+	m := NewMigrator(rewriteCatalog())
+
+	notices, err := m.Deprecated("1.11.0", "1.11.1", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedNotices := []string{
+		`Rule "answer name" in plugin "rewrite" is deprecated in 1.11.1. It is replaced by "answer auto".`,
+		`Rule "answer name" in plugin "rewrite" is deprecated in 1.11.1. It is replaced by "answer auto".`,
+		`Rule "class" in plugin "rewrite" is removed in 1.11.1.`,
+	}
+	checkNotices(t, notices, expectedNotices)
+
+	notices, err = m.Unsupported("1.11.0", "1.11.1", startCorefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNotices(t, notices, []string{`Rule "rcode exact" in plugin "rewrite" is unsupported by this migration tool in 1.11.1.`})
+
+	result, err := m.Migrate("1.11.0", "1.11.1", startCorefile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.:53 {
+    rewrite name regex (.*)\.example\.org {1}.cluster.local answer auto
+    rewrite stop {
+        name suffix .example.org .cluster.local
+        answer auto
+    }
+    rewrite rcode exact SERVFAIL NXDOMAIN
+    forward . /etc/resolv.conf
+}
+`
+	if result != expected {
+		t.Errorf("Expected Corefile:\n%v\nGot:\n%v", expected, result)
+	}
+
+	_, err = m.MigrateWithOptions("1.11.0", "1.11.1", startCorefile, MigrateOptions{UnknownOptions: UnknownError})
+	var merr *MigrationError
+	if !errors.As(err, &merr) || !errors.Is(err, ErrUnsupported) || merr.Rule != "rcode exact" {
+		t.Errorf("Expected an unsupported error for rule 'rcode exact', got '%v'", err)
+	}
+
+	changes, err := m.Changes("1.11.0", "1.11.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, c := range changes {
+		found[c.ToString()] = true
+	}
+	for _, c := range []string{
+		`Rule "answer name" in plugin "rewrite" is deprecated in 1.11.1. It is replaced by "answer auto".`,
+		`Rule "class" in plugin "rewrite" is removed in 1.11.1.`,
+	} {
+		if !found[c] {
+			t.Errorf("Expected change '%v'", c)
+		}
+	}
+}
+
+func TestValidatorRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		server *corefile.ServerBuilder
+		err    error
+	}{
+		{
+			name:   "single line",
+			server: corefile.NewServer(".:53").Plugin("rewrite", "name", "exact", "a", "b", "answer", "auto"),
+		},
+		{
+			name:   "block",
+			server: corefile.NewServer(".:53").Plugin("rewrite", "stop").Option("name", "regex", "(.*)a", "{1}b").Option("answer", "auto"),
+		},
+		{
+			name:   "unsupported rule",
+			server: corefile.NewServer(".:53").Plugin("rewrite", "rcode", "exact", "SERVFAIL", "NXDOMAIN"),
+			err:    ErrUnsupported,
+		},
+		{
+			name:   "unsupported answer",
+			server: corefile.NewServer(".:53").Plugin("rewrite").Option("name", "a", "b").Option("answer", "type", "A", "AAAA"),
+			err:    ErrUnsupported,
+		},
+		{
+			name:   "removed rule",
+			server: corefile.NewServer(".:53").Plugin("rewrite", "class", "CH", "IN"),
+			err:    ErrRemoved,
+		},
+	}
+	v, err := NewMigrator(rewriteCatalog()).ValidatorWithOptions("1.11.1", MigrateOptions{UnknownOptions: UnknownError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.server.WithValidator(v).Build()
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error '%v', got '%v'", test.err, err)
+			}
+		})
+	}
+}
+
+func parsePlugin(t *testing.T, plugin string) *corefile.Plugin {
+	t.Helper()
+	cf, err := corefile.New(".:53 {\n    " + plugin + "\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cf.Servers[0].Plugins[0]
+}
+
+func checkNotices(t *testing.T, notices []Notice, expected []string) {
+	t.Helper()
+	if len(notices) != len(expected) {
+		t.Fatalf("Expected %v notices, got %v: %v", len(expected), len(notices), notices)
+	}
+	for i, n := range notices {
+		if n.ToString() != expected[i] {
+			t.Errorf("Expected notice '%v', got '%v'", expected[i], n.ToString())
+		}
+	}
+}
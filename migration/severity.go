@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"fmt"
+)
+
+// Severity is the state of support/deprecation of a plugin/option in a given release.  Severities are ordered:
+// newdefault < deprecated < ignored < removed < unsupported.
+type Severity string
+
+// severities lists the notice severities in increasing order.
+var severities = []Severity{SevNewDefault, SevDeprecated, SevIgnored, SevRemoved, SevUnsupported}
+
+// Severities returns all severities in increasing order.
+func Severities() []Severity {
+	return append([]Severity{}, severities...)
+}
+
+// ParseSeverity returns the Severity named s.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(s)
+	if sev.rank() < 0 {
+		return "", fmt.Errorf("unknown severity '%v'", s)
+	}
+	return sev, nil
+}
+
+// rank returns the position of the severity in the severity ordering, or -1 if it is unknown.
+func (s Severity) rank() int {
+	for i, sev := range severities {
+		if sev == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compare returns -1, 0 or 1 if s is lower than, equal to or higher than o.  Unknown severities are lower than all
+// known severities.
+func (s Severity) Compare(o Severity) int {
+	switch r, or := s.rank(), o.rank(); {
+	case r < or:
+		return -1
+	case r > or:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast returns true if s is o or higher.
+func (s Severity) AtLeast(o Severity) bool {
+	return s.Compare(o) >= 0
+}
+
+// FilterNotices returns the notices for which keep returns true.
+func FilterNotices(notices []Notice, keep func(Notice) bool) []Notice {
+	filtered := []Notice{}
+	for _, n := range notices {
+		if keep(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+// NoticesWithSeverity returns the notices of any of the given severities.
+func NoticesWithSeverity(notices []Notice, sevs ...Severity) []Notice {
+	return FilterNotices(notices, func(n Notice) bool {
+		for _, sev := range sevs {
+			if n.Severity == sev {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NoticesAtLeast returns the notices of severity min or higher.
+func NoticesAtLeast(notices []Notice, min Severity) []Notice {
+	return FilterNotices(notices, func(n Notice) bool { return n.Severity.AtLeast(min) })
+}
+
+// MaxSeverity returns the highest severity of the notices, or false if there are no notices.
+func MaxSeverity(notices []Notice) (Severity, bool) {
+	if len(notices) == 0 {
+		return "", false
+	}
+	max := notices[0].Severity
+	for _, n := range notices[1:] {
+		if n.Severity.Compare(max) > 0 {
+			max = n.Severity
+		}
+	}
+	return max, true
+}
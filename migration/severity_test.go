@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"testing"
+)
+
+func TestSeverity(t *testing.T) {
+	for i, sev := range severities {
+		for j, o := range severities {
+			if got, want := sev.AtLeast(o), i >= j; got != want {
+				t.Errorf("expected %v.AtLeast(%v) to be %v, got %v", sev, o, want, got)
+			}
+		}
+	}
+
+	if _, err := ParseSeverity("removed"); err != nil {
+		t.Errorf("expected 'removed' to parse, got '%v'", err)
+	}
+	for _, s := range []string{"all", "", "REMOVED"} {
+		if _, err := ParseSeverity(s); err == nil {
+			t.Errorf("expected '%v' to fail", s)
+		}
+	}
+
+	notices := []Notice{
+		{Plugin: "proxy", Severity: SevDeprecated, Version: "1.4.0"},
+		{Plugin: "ready", Severity: SevNewDefault, Version: "1.5.0"},
+		{Plugin: "proxy", Severity: SevRemoved, Version: "1.5.0"},
+		{Plugin: "route53", Severity: SevUnsupported, Version: "1.5.0"},
+	}
+	if got := NoticesAtLeast(notices, SevRemoved); len(got) != 2 || got[0].Plugin != "proxy" || got[1].Plugin != "route53" {
+		t.Errorf("unexpected notices at least removed: %v", got)
+	}
+	if got := NoticesWithSeverity(notices, SevNewDefault, SevDeprecated); len(got) != 2 || got[0].Severity != SevDeprecated {
+		t.Errorf("unexpected notices with severity: %v", got)
+	}
+	if max, ok := MaxSeverity(notices[:3]); !ok || max != SevRemoved {
+		t.Errorf("expected max severity removed, got %v", max)
+	}
+	if _, ok := MaxSeverity(nil); ok {
+		t.Errorf("expected no max severity for no notices")
+	}
+}
@@ -0,0 +1,114 @@
+package migration
+
+import (
+	"fmt"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+// Validator returns a corefile.Validator for building Corefiles for the CoreDNS version.  See
+// Migrator.ValidatorWithOptions.
+func Validator(coreDNSVersion string) (corefile.Validator, error) {
+	return defaultMigrator.Validator(coreDNSVersion)
+}
+
+// ValidatorWithOptions returns a corefile.Validator for building Corefiles for the CoreDNS version.  See
+// Migrator.ValidatorWithOptions.
+func ValidatorWithOptions(coreDNSVersion string, opts MigrateOptions) (corefile.Validator, error) {
+	return defaultMigrator.ValidatorWithOptions(coreDNSVersion, opts)
+}
+
+// Validator returns a corefile.Validator for building Corefiles for the CoreDNS version, accepting the plugins/options
+// unknown to the Migrator's catalog.  See Migrator.ValidatorWithOptions.
+func (m *Migrator) Validator(coreDNSVersion string) (corefile.Validator, error) {
+	return m.ValidatorWithOptions(coreDNSVersion, MigrateOptions{})
+}
+
+// ValidatorWithOptions returns a corefile.Validator for building Corefiles for the CoreDNS version, using the
+// Migrator's catalog.  It rejects the plugins/options removed from CoreDNS by that version (ErrRemoved), and those
+// ignored by CoreDNS in that version (ErrIgnored).  Plugins/options unknown to the catalog are accepted, unless
+// opts.UnknownPlugins/opts.UnknownOptions is UnknownError (ErrUnsupported), as in a migration.  Other options are
+// not used.
+func (m *Migrator) ValidatorWithOptions(coreDNSVersion string, opts MigrateOptions) (corefile.Validator, error) {
+	v, err := m.ResolveVersion(coreDNSVersion)
+	if err != nil {
+		return nil, err
+	}
+	plugins, cps := m.catalog[v].plugins, m.compiledPlugins(v)
+	return func(p *corefile.Plugin, o *corefile.Option) error {
+		vp, present := plugins[p.Name]
+		if !present {
+			if opts.UnknownPlugins == UnknownError {
+				return fmt.Errorf("plugin '%v' %w in CoreDNS %v", p.Name, ErrUnsupported, v)
+			}
+			return nil
+		}
+		if err := statusError(vp.status, fmt.Sprintf("plugin '%v'", p.Name), v); err != nil {
+			return err
+		}
+		if len(vp.rules) > 0 {
+			return validateRule(p, o, vp, v, opts)
+		}
+		if o == nil {
+			return nil
+		}
+		vo, present := cps[p.Name].matchOption(o)
+		if !present {
+			if opts.UnknownOptions == UnknownError {
+				return fmt.Errorf("option '%v' of plugin '%v' %w in CoreDNS %v", o.Name, p.Name, ErrUnsupported, v)
+			}
+			return nil
+		}
+		return statusError(vo.status, fmt.Sprintf("option '%v' of plugin '%v'", o.Name, p.Name), v)
+	}, nil
+}
+
+// statusError returns the error rejecting a plugin/option with the status in the CoreDNS version, or nil if it is
+// valid.
+func statusError(status Severity, what, v string) error {
+	switch status {
+	case SevRemoved:
+		return fmt.Errorf("%v %w %v", what, ErrRemoved, v)
+	case SevIgnored:
+		return fmt.Errorf("%v %w %v", what, ErrIgnored, v)
+	}
+	return nil
+}
+
+// validateRule checks the rule of a plugin with rules, e.g. rewrite, as it is built: the rule given on the plugin's
+// line, or the rule or response rewrite given by an option of its block.
+func validateRule(p *corefile.Plugin, o *corefile.Option, vp plugin, v string, opts MigrateOptions) error {
+	var found, missing []string
+	switch {
+	case o != nil && o.Name == "answer":
+		if len(o.Args) == 0 {
+			return nil
+		}
+		form := "answer " + o.Args[0]
+		if _, ok := vp.rules[form]; ok {
+			found = append(found, form)
+		} else {
+			missing = append(missing, form)
+		}
+	default:
+		args := p.Args
+		if o != nil {
+			args = append([]string{o.Name}, o.Args...)
+		}
+		r, err := ParseRewriteRule(&corefile.Plugin{Name: p.Name, Args: args})
+		if err != nil {
+			// the rule is given in a block, and checked as its options are added
+			return nil
+		}
+		found, missing = matchRule(r, vp)
+	}
+	if len(missing) > 0 && opts.UnknownOptions == UnknownError {
+		return fmt.Errorf("rule '%v' of plugin '%v' %w in CoreDNS %v", missing[0], p.Name, ErrUnsupported, v)
+	}
+	for _, form := range found {
+		if err := statusError(vp.rules[form].status, fmt.Sprintf("rule '%v' of plugin '%v'", form, p.Name), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
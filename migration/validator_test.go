@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coredns/corefile-migration/migration/corefile"
+)
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		opts    MigrateOptions
+		server  *corefile.ServerBuilder
+		err     error
+	}{
+		{
+			name:    "valid",
+			version: "1.11.1",
+			server:  corefile.NewServer(".:53").Plugin("forward", ".", "/etc/resolv.conf").Option("max_concurrent", "1000"),
+		},
+		{
+			name:    "unknown plugin",
+			version: "1.11.1",
+			server:  corefile.NewServer(".:53").Plugin("myplugin", "arg").Option("myoption"),
+		},
+		{
+			name:    "unsupported plugin",
+			version: "1.11.1",
+			opts:    MigrateOptions{UnknownPlugins: UnknownError},
+			server:  corefile.NewServer(".:53").Plugin("proxy", ".", "/etc/resolv.conf"),
+			err:     ErrUnsupported,
+		},
+		{
+			name:    "removed plugin",
+			version: "1.5.0",
+			server:  corefile.NewServer(".:53").Plugin("proxy", ".", "/etc/resolv.conf"),
+			err:     ErrRemoved,
+		},
+		{
+			name:    "unknown option",
+			version: "1.11.1",
+			opts:    MigrateOptions{UnknownPlugins: UnknownError},
+			server:  corefile.NewServer(".:53").Plugin("forward", ".", "/etc/resolv.conf").Option("no_such_option"),
+		},
+		{
+			name:    "unsupported option",
+			version: "1.11.1",
+			opts:    MigrateOptions{UnknownOptions: UnknownError},
+			server:  corefile.NewServer(".:53").Plugin("forward", ".", "/etc/resolv.conf").Option("no_such_option"),
+			err:     ErrUnsupported,
+		},
+		{
+			name:    "ignored option",
+			version: "1.4.0",
+			server:  corefile.NewServer(".:53").Plugin("kubernetes", "cluster.local").Option("endpoint", "https://10.0.0.1"),
+			err:     ErrIgnored,
+		},
+		{
+			name:    "removed option",
+			version: "1.8.0",
+			server:  corefile.NewServer(".:53").Plugin("kubernetes", "cluster.local").Option("transfer", "to", "*"),
+			err:     ErrRemoved,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := ValidatorWithOptions(test.version, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = test.server.WithValidator(v).Build()
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error '%v', got '%v'", test.err, err)
+			}
+		})
+	}
+
+	if _, err := Validator("0.1.0"); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Expected error '%v', got '%v'", ErrUnknownVersion, err)
+	}
+}
@@ -0,0 +1,159 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed CoreDNS version.
+type Version struct {
+	Major  int
+	Minor  int
+	Patch  int
+	Suffix string // pre-release, build or vendor suffix (e.g. "-eks.1"), ignored when comparing versions
+}
+
+// ParseVersion parses a CoreDNS version string.  A leading "v" is accepted, as are pre-release, build and vendor
+// suffixes, e.g. "v1.11.1", "1.11.1-eks.1" or "1.11.1+build.2".  A missing patch number is read as 0.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	str := strings.TrimSpace(s)
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "v"), "V")
+	if i := strings.IndexAny(str, "-+"); i >= 0 {
+		v.Suffix = str[i:]
+		str = str[:i]
+	}
+	segs := strings.Split(str, ".")
+	if len(segs) < 2 || len(segs) > 3 {
+		return v, fmt.Errorf("%w '%v': invalid version format", ErrUnknownVersion, s)
+	}
+	nums := make([]int, 3)
+	for i, seg := range segs {
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%w '%v': invalid version format", ErrUnknownVersion, s)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// NormalizeVersion returns the version string in the form used by the release catalog, e.g. "1.11.1" for
+// "v1.11.1-eks.1".
+func NormalizeVersion(s string) (string, error) {
+	v, err := ParseVersion(s)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// String returns the version in the form used by the release catalog, without any suffix.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or higher than o.  Suffixes are ignored.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Less returns true if v is lower than o.
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// UnknownPatchPolicy defines how a Migrator resolves versions that are not in its release catalog, but belong to a
+// minor release that is, e.g. a patch release newer than the catalog.
+type UnknownPatchPolicy int
+
+const (
+	PatchError   UnknownPatchPolicy = iota // reject unknown patch releases
+	PatchNearest                           // use the nearest known patch release of the same minor release
+)
+
+// ResolveVersion returns the version in the default catalog matching s.  See Migrator.ResolveVersion.
+func ResolveVersion(s string) (string, error) {
+	return defaultMigrator.ResolveVersion(s)
+}
+
+// ResolveVersion returns the version in the Migrator's catalog matching s.  The version is normalized first (see
+// NormalizeVersion).  Versions missing from the catalog are resolved according to the Migrator's UnknownPatchPolicy.
+func (m *Migrator) ResolveVersion(s string) (string, error) {
+	if _, ok := m.catalog[s]; ok {
+		return s, nil
+	}
+	v, err := ParseVersion(s)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := m.catalog[v.String()]; ok {
+		return v.String(), nil
+	}
+	if m.unknownPatch == PatchNearest {
+		nearest := ""
+		distance := -1
+		for _, known := range m.ValidVersions() {
+			kv, err := ParseVersion(known)
+			if err != nil || kv.Major != v.Major || kv.Minor != v.Minor {
+				continue
+			}
+			d := kv.Patch - v.Patch
+			if d < 0 {
+				d = -d
+			}
+			// on a tie, the lower release wins since ValidVersions is sorted
+			if distance < 0 || d < distance {
+				nearest, distance = known, d
+			}
+		}
+		if nearest != "" {
+			return nearest, nil
+		}
+	}
+	return "", fmt.Errorf("%w '%v'", ErrUnknownVersion, s)
+}
+
+// resolveVersions resolves a pair of from/to versions.
+func (m *Migrator) resolveVersions(fromCoreDNSVersion, toCoreDNSVersion string) (string, string, error) {
+	from, err := m.ResolveVersion(fromCoreDNSVersion)
+	if err != nil {
+		return "", "", err
+	}
+	to, err := m.ResolveVersion(toCoreDNSVersion)
+	if err != nil {
+		return "", "", err
+	}
+	return from, to, nil
+}
+
+// sortVersions sorts version strings in ascending order.  Strings that cannot be parsed as versions are sorted last.
+func sortVersions(vStrs []string) {
+	sort.Slice(vStrs, func(i, j int) bool {
+		iv, iErr := ParseVersion(vStrs[i])
+		jv, jErr := ParseVersion(vStrs[j])
+		switch {
+		case iErr != nil && jErr != nil:
+			return vStrs[i] < vStrs[j]
+		case iErr != nil:
+			return false
+		case jErr != nil:
+			return true
+		}
+		if c := iv.Compare(jv); c != 0 {
+			return c < 0
+		}
+		return vStrs[i] < vStrs[j]
+	})
+}
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		version   string
+		expected  Version
+		shouldErr bool
+	}{
+		{version: "1.11.1", expected: Version{Major: 1, Minor: 11, Patch: 1}},
+		{version: "v1.11.1", expected: Version{Major: 1, Minor: 11, Patch: 1}},
+		{version: "1.11.1-eks.1", expected: Version{Major: 1, Minor: 11, Patch: 1, Suffix: "-eks.1"}},
+		{version: "v1.8.7-eksbuild.3", expected: Version{Major: 1, Minor: 8, Patch: 7, Suffix: "-eksbuild.3"}},
+		{version: "1.11.1+build.2", expected: Version{Major: 1, Minor: 11, Patch: 1, Suffix: "+build.2"}},
+		{version: "1.12", expected: Version{Major: 1, Minor: 12}},
+		{version: "banana", shouldErr: true},
+		{version: "1.x.1", shouldErr: true},
+		{version: "1.2.3.4", shouldErr: true},
+		{version: "", shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		v, err := ParseVersion(tc.version)
+		if tc.shouldErr {
+			if !errors.Is(err, ErrUnknownVersion) {
+				t.Errorf("expected '%v' to fail with ErrUnknownVersion, got '%v'", tc.version, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to parse, got '%v'", tc.version, err)
+			continue
+		}
+		if v != tc.expected {
+			t.Errorf("expected '%v' to parse as %+v, got %+v", tc.version, tc.expected, v)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.9.4", "1.10.0", -1},
+		{"1.10.0", "1.9.4", 1},
+		{"v1.11.1", "1.11.1-eks.1", 0},
+		{"2.0.0", "1.14.2", 1},
+	}
+	for _, tc := range testCases {
+		a, _ := ParseVersion(tc.a)
+		b, _ := ParseVersion(tc.b)
+		if got := a.Compare(b); got != tc.expected {
+			t.Errorf("expected %v compared to %v to be %v, got %v", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		policy   UnknownPatchPolicy
+		expected string
+	}{
+		{version: "1.11.1", expected: "1.11.1"},
+		{version: "v1.11.1", expected: "1.11.1"},
+		{version: "1.11.1-eks.1", expected: "1.11.1"},
+		{version: "1.11.2"},
+		{version: "1.11.2", policy: PatchNearest, expected: "1.11.1"},
+		{version: "1.14.9", policy: PatchNearest, expected: "1.14.2"},
+		{version: "1.8.1", policy: PatchNearest, expected: "1.8.0"},
+		{version: "1.99.0", policy: PatchNearest},
+		{version: "banana", policy: PatchNearest},
+	}
+
+	for _, tc := range testCases {
+		m := NewMigrator(Versions, WithUnknownPatchPolicy(tc.policy))
+		v, err := m.ResolveVersion(tc.version)
+		if tc.expected == "" {
+			if !errors.Is(err, ErrUnknownVersion) {
+				t.Errorf("expected '%v' to fail with ErrUnknownVersion, got '%v' (%v)", tc.version, v, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected '%v' to resolve, got '%v'", tc.version, err)
+			continue
+		}
+		if v != tc.expected {
+			t.Errorf("expected '%v' to resolve to '%v', got '%v'", tc.version, tc.expected, v)
+		}
+	}
+
+	if _, err := Migrate("v1.10.1", "1.11.1-eks.1", ".:53 {\n    forward . /etc/resolv.conf\n}\n", false); err != nil {
+		t.Errorf("expected migration between normalized versions to succeed, got '%v'", err)
+	}
+}
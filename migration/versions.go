@@ -9,9 +9,14 @@ type release struct {
 	k8sReleases    []string          // a list of K8s versions that deploy this CoreDNS release by default
 	nextVersion    string            // the next CoreDNS version
 	priorVersion   string            // the prior CoreDNS version
-	dockerImageSHA string            // the docker image SHA for this release
+	dockerImageSHA string            // the docker image SHA (manifest list digest) for this release
 	plugins        map[string]plugin // map of plugins with deprecation status and migration actions for this release
 
+	// platformDigests holds the per-platform image digests of this release, keyed by platform (e.g. "linux/amd64",
+	//   "linux/arm/v7").  Digests are matched by VersionFromImage, VersionFromSHA and Released in addition to
+	//   dockerImageSHA.
+	platformDigests map[string]string
+
 	// pre/postProcess are processing actions to take on the corefile as a whole.  Used for complex migration
 	//   tasks that dont fit well into the modular plugin/option migration framework. For example, when the
 	//   action on a plugin would need to extend beyond the scope of that plugin (affecting other plugins, or
@@ -28,7 +33,8 @@ type release struct {
 	defaultConf string
 }
 
-// Versions holds a map of plugin/option migrations per CoreDNS release (since 1.1.4)
+// Versions holds a map of plugin/option migrations per CoreDNS release (since 1.1.4).  It is read once, when the package
+// is initialized; use DefaultCatalog and NewMigrator to migrate against a modified catalog.
 var Versions = map[string]release{
 	"1.14.2": {
 		priorVersion:   "1.14.1",
@@ -86,12 +92,14 @@ var Versions = map[string]release{
 	"1.12.1": {
 		nextVersion:    "1.12.2",
 		priorVersion:   "1.12.0",
+		k8sReleases:    []string{"1.34"},
 		dockerImageSHA: "e8c262566636e6bc340ece6473b0eed193cad045384401529721ddbe6463d31c",
 		plugins:        plugins_1_12_0,
 	},
 	"1.12.0": {
 		nextVersion:    "1.12.1",
 		priorVersion:   "1.11.4",
+		k8sReleases:    []string{"1.33"},
 		dockerImageSHA: "40384aa1f5ea6bfdc77997d243aec73da05f27aed0c5e9d65bfa98933c519d97",
 		plugins:        plugins_1_12_0,
 	},
@@ -104,12 +112,14 @@ var Versions = map[string]release{
 	"1.11.3": {
 		nextVersion:    "1.11.4",
 		priorVersion:   "1.11.1",
+		k8sReleases:    []string{"1.31", "1.32"},
 		dockerImageSHA: "9caabbf6238b189a65d0d6e6ac138de60d6a1c419e5a341fbbb7c78382559c6e",
-		plugins:        plugins_1_11_0,
+		plugins:        plugins_1_11_3,
 	},
 	"1.11.1": {
 		nextVersion:    "1.11.3",
 		priorVersion:   "1.11.0",
+		k8sReleases:    []string{"1.29", "1.30"},
 		dockerImageSHA: "1eeb4c7316bacb1d4c8ead65571cd92dd21e27359f0d4917f1a5822a73b75db1",
 		plugins:        plugins_1_11_0,
 	},
@@ -122,6 +132,7 @@ var Versions = map[string]release{
 	"1.10.1": {
 		nextVersion:    "1.11.0",
 		priorVersion:   "1.10.0",
+		k8sReleases:    []string{"1.27", "1.28"},
 		dockerImageSHA: "a0ead06651cf580044aeb0a0feba63591858fb2e43ade8c9dea45a6a89ae7e5e",
 		plugins:        plugins_1_10_1,
 	},
@@ -129,7 +140,7 @@ var Versions = map[string]release{
 		nextVersion:    "1.10.1",
 		priorVersion:   "1.9.4",
 		dockerImageSHA: "017727efcfeb7d053af68e51436ce8e65edbc6ca573720afb4f79c8594036955",
-		plugins:        plugins_1_9_4,
+		plugins:        plugins_1_10_0,
 	},
 	"1.9.4": {
 		nextVersion:    "1.10.0",
@@ -140,6 +151,7 @@ var Versions = map[string]release{
 	"1.9.3": {
 		nextVersion:    "1.9.4",
 		priorVersion:   "1.9.2",
+		k8sReleases:    []string{"1.25", "1.26"},
 		dockerImageSHA: "8e352a029d304ca7431c6507b56800636c321cb52289686a581ab70aaa8a2e2a",
 		plugins:        plugins_1_9_3,
 	},
@@ -170,6 +182,7 @@ var Versions = map[string]release{
 	"1.8.6": {
 		nextVersion:    "1.8.7",
 		priorVersion:   "1.8.5",
+		k8sReleases:    []string{"1.23", "1.24"},
 		dockerImageSHA: "5b6ec0d6de9baaf3e92d0f66cd96a25b9edbce8716f5f15dcd1a616b3abd590e",
 		plugins:        plugins_1_8_3,
 	},
@@ -182,6 +195,7 @@ var Versions = map[string]release{
 	"1.8.4": {
 		nextVersion:    "1.8.5",
 		priorVersion:   "1.8.3",
+		k8sReleases:    []string{"1.22"},
 		dockerImageSHA: "6e5a02c21641597998b4be7cb5eb1e7b02c0d8d23cce4dd09f4682d463798890",
 		plugins:        plugins_1_8_3,
 	},
@@ -206,7 +220,7 @@ var Versions = map[string]release{
 			"k8s_external": plugins["k8s_external"]["v1"],
 			"prometheus":   {},
 			"forward":      plugins["forward"]["v3"],
-			"cache":        plugins["cache"]["v1"],
+			"cache":        plugins["cache"]["v2"],
 			"loop":         {},
 			"reload":       {},
 			"loadbalance":  {},
@@ -230,7 +244,7 @@ var Versions = map[string]release{
 			"k8s_external": plugins["k8s_external"]["v1"],
 			"prometheus":   {},
 			"forward":      plugins["forward"]["v3"],
-			"cache":        plugins["cache"]["v1"],
+			"cache":        plugins["cache"]["v2"],
 			"loop":         {},
 			"reload":       {},
 			"loadbalance":  {},
@@ -273,7 +287,7 @@ var Versions = map[string]release{
 			"k8s_external": plugins["k8s_external"]["v1"],
 			"prometheus":   {},
 			"forward":      plugins["forward"]["v3 add max_concurrent"],
-			"cache":        plugins["cache"]["v1"],
+			"cache":        plugins["cache"]["v2"],
 			"loop":         {},
 			"reload":       {},
 			"loadbalance":  {},
@@ -850,7 +864,7 @@ var plugins_1_14_0 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v3"],
+	"rewrite":      plugins["rewrite"]["v5"],
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -869,7 +883,7 @@ var plugins_1_13_0 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v3"],
+	"rewrite":      plugins["rewrite"]["v5"],
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -888,7 +902,7 @@ var plugins_1_12_0 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v3"],
+	"rewrite":      plugins["rewrite"]["v5"],
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -907,7 +921,26 @@ var plugins_1_11_4 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v3"],
+	"rewrite":      plugins["rewrite"]["v5"],
+	"transfer":     plugins["transfer"]["v1"],
+}
+
+var plugins_1_11_3 = map[string]plugin{
+	"errors":       plugins["errors"]["v3"],
+	"log":          plugins["log"]["v1"],
+	"health":       plugins["health"]["v1"],
+	"ready":        {},
+	"autopath":     {},
+	"kubernetes":   plugins["kubernetes"]["v8"],
+	"k8s_external": plugins["k8s_external"]["v2"],
+	"prometheus":   {},
+	"forward":      plugins["forward"]["v3"],
+	"cache":        plugins["cache"]["v4"],
+	"loop":         {},
+	"reload":       {},
+	"loadbalance":  {},
+	"hosts":        plugins["hosts"]["v1"],
+	"rewrite":      plugins["rewrite"]["v5"], // add rcode rule
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -926,7 +959,7 @@ var plugins_1_11_0 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v3"], // add cname_target option
+	"rewrite":      plugins["rewrite"]["v4"], // add cname_target option
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -945,7 +978,26 @@ var plugins_1_10_1 = map[string]plugin{
 	"reload":       {},
 	"loadbalance":  {},
 	"hosts":        plugins["hosts"]["v1"],
-	"rewrite":      plugins["rewrite"]["v2"],
+	"rewrite":      plugins["rewrite"]["v3"],
+	"transfer":     plugins["transfer"]["v1"],
+}
+
+var plugins_1_10_0 = map[string]plugin{
+	"errors":       plugins["errors"]["v3"],
+	"log":          plugins["log"]["v1"],
+	"health":       plugins["health"]["v1"],
+	"ready":        {},
+	"autopath":     {},
+	"kubernetes":   plugins["kubernetes"]["v8"],
+	"k8s_external": plugins["k8s_external"]["v1"],
+	"prometheus":   {},
+	"forward":      plugins["forward"]["v3"],
+	"cache":        plugins["cache"]["v3"],
+	"loop":         {},
+	"reload":       {},
+	"loadbalance":  {},
+	"hosts":        plugins["hosts"]["v1"],
+	"rewrite":      plugins["rewrite"]["v3"], // add answer value and answer auto response rewrites
 	"transfer":     plugins["transfer"]["v1"],
 }
 
@@ -978,7 +1030,7 @@ var plugins_1_9_3 = map[string]plugin{
 	"k8s_external": plugins["k8s_external"]["v1"],
 	"prometheus":   {},
 	"forward":      plugins["forward"]["v3"],
-	"cache":        plugins["cache"]["v1"],
+	"cache":        plugins["cache"]["v2"],
 	"loop":         {},
 	"reload":       {},
 	"loadbalance":  {},
@@ -997,7 +1049,7 @@ var plugins_1_8_3 = map[string]plugin{
 	"k8s_external": plugins["k8s_external"]["v1"],
 	"prometheus":   {},
 	"forward":      plugins["forward"]["v3"],
-	"cache":        plugins["cache"]["v1"],
+	"cache":        plugins["cache"]["v2"],
 	"loop":         {},
 	"reload":       {},
 	"loadbalance":  {},